@@ -0,0 +1,81 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+type BifurcationType int
+
+const (
+	// The branch turns back in the parameter. Detected by a change of sign
+	// of the parameter component of the tangent.
+	Fold BifurcationType = iota
+
+	// Another branch crosses. Detected by a change of sign of the
+	// determinant of the bordered Newton matrix.
+	BranchPoint
+)
+
+func (bt BifurcationType) String() string {
+	switch bt {
+	case Fold:
+		return "fold"
+	case BranchPoint:
+		return "branch point"
+	}
+	return "unknown"
+}
+
+// A located bifurcation on a continuation branch
+type BifurcationPoint struct {
+	Type      BifurcationType
+	Parameter float64
+	Norm      float64 // root mean square of X over the mesh
+	X         []matrix.Matrix
+
+	// the bifurcation lies between Points[Index-1] and Points[Index] of the
+	// ContinuationResult
+	Index int
+}
+
+// Locates a bifurcation on the continuation step of size ds from (x0, beta0)
+// along (sx, sl) by bisection on the step size. dl0 and tau0 are the test
+// functions at the start of the step. X and Beta are left at the located
+// point.
+func (bvp *BVP) locateBifurcation(opts ContinuationOptions, kind BifurcationType, x0 []*matrix.DenseMatrix, beta0 matrix.MatrixRO, sx []*matrix.DenseMatrix, sl, ds, dl0, tau0 float64) (point BifurcationPoint, err error) {
+	p := opts.Parameter
+
+	lo, hi := 0., ds
+	for k := 0; k < opts.MaxLocate && hi-lo > opts.LocateTolerance; k++ {
+		mid := (lo + hi) / 2
+
+		_, err = bvp.continuationStep(opts, x0, beta0, sx, sl, mid)
+		if err != nil {
+			return
+		}
+
+		_, dl, tau, err := branchTangent(bvp, p, sx, sl)
+		if err != nil {
+			return point, err
+		}
+
+		same := sign(dl) == sign(dl0)
+		if kind == BranchPoint {
+			same = tau == tau0
+		}
+
+		if same {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	iterations, err := bvp.continuationStep(opts, x0, beta0, sx, sl, (lo+hi)/2)
+	if err != nil {
+		return
+	}
+
+	located := bvp.continuationPoint(p, iterations, (lo+hi)/2)
+	return BifurcationPoint{kind, located.Parameter, located.Norm, located.X, 0}, nil
+}
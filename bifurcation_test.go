@@ -0,0 +1,113 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+// y'' + lambda sin y = 0, y(0) = y(pi) = 0, with lambda = beta[0]. Branches
+// of nontrivial solutions bifurcate from y = 0 at lambda = 1, 4, 9, ...
+var pendulumEigenODE = NewODEWithParamJacobian(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -beta.Get(0, 0) * math.Sin(x.Get(0, 0))}, 2, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, 1, -beta.Get(0, 0) * math.Cos(x.Get(0, 0)), 0}, 2, 2)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, -math.Sin(x.Get(0, 0))}, 2, 1)
+	},
+	2, 1,
+)
+
+func TestDetectFold(t *testing.T) {
+	bvp, err := newBratuBVP(51, 1.5)
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	opts := DefaultContinuationOptions()
+	opts.Step = 0.2
+	opts.MaxStep = 0.3
+	opts.Min = 1
+	opts.MaxPoints = 200
+	opts.DetectBifurcations = true
+
+	result, err := bvp.Continuation(opts)
+
+	if err != nil {
+		t.Errorf("Error in continuation: %s", err)
+	}
+
+	if len(result.Bifurcations) != 1 {
+		t.Errorf("Expected one bifurcation, got %d", len(result.Bifurcations))
+		return
+	}
+
+	fold := result.Bifurcations[0]
+
+	if fold.Type != Fold {
+		t.Errorf("Expected a fold, got a %s", fold.Type)
+	}
+
+	maxLambda := 0.
+	for _, point := range result.Points {
+		maxLambda = math.Max(maxLambda, point.Parameter)
+	}
+
+	if fold.Parameter < maxLambda || fold.Parameter-bratuFold > 0.005 {
+		t.Errorf("Fold located at lambda = %g, expected near %g", fold.Parameter, bratuFold)
+	}
+
+	if fold.Index < 1 || result.Points[fold.Index-1].Parameter > fold.Parameter || result.Points[fold.Index].Parameter > fold.Parameter {
+		t.Errorf("Fold reported between the wrong points")
+	}
+}
+
+func TestDetectBranchPoint(t *testing.T) {
+	timeMesh := make([]float64, 51)
+	for i := range timeMesh {
+		timeMesh[i] = math.Pi * float64(i) / 50
+	}
+
+	B0 := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0}, 2, 2)
+	B1 := matrix.MakeDenseMatrix([]float64{0, 0, 1, 0}, 2, 2)
+	beta := matrix.MakeDenseMatrix([]float64{0.5}, 1, 1)
+
+	bvp, err := NewBVPWithoutInitialGuess(pendulumEigenODE, timeMesh, B0, B1, beta, matrix.Zeros(2, 1))
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	opts := DefaultContinuationOptions()
+	opts.Step = 0.1
+	opts.MaxStep = 0.2
+	opts.Max = 2
+	opts.DetectBifurcations = true
+
+	result, err := bvp.Continuation(opts)
+
+	if err != nil {
+		t.Errorf("Error in continuation: %s", err)
+	}
+
+	if len(result.Bifurcations) != 1 {
+		t.Errorf("Expected one bifurcation, got %d", len(result.Bifurcations))
+		return
+	}
+
+	bp := result.Bifurcations[0]
+
+	if bp.Type != BranchPoint {
+		t.Errorf("Expected a branch point, got a %s", bp.Type)
+	}
+
+	if math.Abs(bp.Parameter-1) > 0.01 {
+		t.Errorf("Branch point located at lambda = %g, expected near 1", bp.Parameter)
+	}
+}
@@ -0,0 +1,145 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+// Two point boundary conditions g(x(t_1), x(t_n), beta) = 0, where g has as
+// many components as the ODE has variables, plus one for each entry of
+// FreeParameters
+type BoundaryCondition interface {
+	Residual(xa, xb, beta matrix.MatrixRO) (matrix.Matrix, error)
+
+	// Jacobians of the residual with respect to xa and xb
+	Jacobian(xa, xb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix, err error)
+}
+
+// Linear boundary conditions B0 x(t_1) + B1 x(t_n) = B
+type LinearBoundaryCondition struct {
+	B0, B1, B matrix.Matrix
+}
+
+func (bc LinearBoundaryCondition) Residual(xa, xb, beta matrix.MatrixRO) (matrix.Matrix, error) {
+	return matrix.Difference(matrix.Sum(matrix.Product(bc.B0, xa), matrix.Product(bc.B1, xb)), bc.B), nil
+}
+
+func (bc LinearBoundaryCondition) Jacobian(xa, xb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix, err error) {
+	return bc.B0, bc.B1, nil
+}
+
+// Boundary conditions given by a function g and optionally its Jacobians
+type NonlinearBoundaryCondition struct {
+	g  func(xa, xb, beta matrix.MatrixRO) matrix.Matrix
+	dg func(xa, xb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix)
+
+	P int // number of variables (length of xa and xb)
+}
+
+// dg may be nil, in which case the Jacobians are computed by central
+// differences of g
+func NewNonlinearBoundaryCondition(g func(xa, xb, beta matrix.MatrixRO) matrix.Matrix, dg func(xa, xb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix), p int) NonlinearBoundaryCondition {
+	return NonlinearBoundaryCondition{g, dg, p}
+}
+
+// Evaluates g with checking of matrix dimensions
+func (bc NonlinearBoundaryCondition) Residual(xa, xb, beta matrix.MatrixRO) (matrix.Matrix, error) {
+	if xa.Rows() != bc.P || xa.Cols() != 1 {
+		return nil, NewDimensionErrorIn("NonlinearBoundaryCondition.Residual", "xa", bc.P, 1, xa.Rows(), xa.Cols())
+	}
+
+	if xb.Rows() != bc.P || xb.Cols() != 1 {
+		return nil, NewDimensionErrorIn("NonlinearBoundaryCondition.Residual", "xb", bc.P, 1, xb.Rows(), xb.Cols())
+	}
+
+	return bc.g(xa, xb, beta), nil
+}
+
+// Evaluates dg, or its central difference approximation if dg is nil
+func (bc NonlinearBoundaryCondition) Jacobian(xa, xb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix, err error) {
+	if bc.dg != nil {
+		if _, err = bc.Residual(xa, xb, beta); err != nil {
+			return
+		}
+		Ba, Bb = bc.dg(xa, xb, beta)
+		return
+	}
+
+	Ba, err = centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		return bc.Residual(v, xb, beta)
+	}, xa)
+	if err != nil {
+		return
+	}
+
+	Bb, err = centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		return bc.Residual(xa, v, beta)
+	}, xb)
+	return
+}
+
+// Returns the boundary conditions of the BVP, built from B0, B1 and B if BC
+// is nil
+func (bvp *BVP) boundaryCondition() BoundaryCondition {
+	if bvp.BC == nil {
+		return LinearBoundaryCondition{bvp.B0, bvp.B1, bvp.B}
+	}
+	return bvp.BC
+}
+
+// Evaluates the boundary conditions at the ends of X, or the multipoint
+// conditions, with checking of matrix dimensions
+func (bvp *BVP) boundaryResidual() (*matrix.DenseMatrix, error) {
+	if bvp.Multipoint != nil {
+		return bvp.Multipoint.residual(bvp.X), nil
+	}
+
+	g, err := bvp.boundaryCondition().Residual(bvp.X[0], bvp.X[bvp.N-1], bvp.Beta)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := bvp.ODE.P + len(bvp.FreeParameters)
+	if g.Rows() != rows || g.Cols() != 1 {
+		return nil, NewDimensionErrorIn("BoundaryCondition.Residual", "g", rows, 1, g.Rows(), g.Cols())
+	}
+
+	return matrix.MakeDenseCopy(g), nil
+}
+
+// Evaluates the two point boundary Jacobians at the ends of X with checking
+// of matrix dimensions
+func (bvp *BVP) boundaryJacobian() (Ba, Bb *matrix.DenseMatrix, err error) {
+	a, b, err := bvp.boundaryCondition().Jacobian(bvp.X[0], bvp.X[bvp.N-1], bvp.Beta)
+	if err != nil {
+		return
+	}
+
+	p := bvp.ODE.P
+	rows := p + len(bvp.FreeParameters)
+	if a.Rows() != rows || a.Cols() != p {
+		return nil, nil, NewDimensionErrorIn("BoundaryCondition.Jacobian", "Ba", rows, p, a.Rows(), a.Cols())
+	}
+
+	if b.Rows() != rows || b.Cols() != p {
+		return nil, nil, NewDimensionErrorIn("BoundaryCondition.Jacobian", "Bb", rows, p, b.Rows(), b.Cols())
+	}
+
+	return matrix.MakeDenseCopy(a), matrix.MakeDenseCopy(b), nil
+}
+
+// Applies the linearised boundary conditions to the mesh function delta
+func (bvp *BVP) boundaryProduct(delta []*matrix.DenseMatrix) (*matrix.DenseMatrix, error) {
+	if bvp.Multipoint != nil {
+		product := matrix.Zeros(bvp.ODE.P, 1)
+		for k, j := range bvp.Multipoint.Indices {
+			product.Add(matrix.Product(bvp.Multipoint.B[k], delta[j]))
+		}
+		return product, nil
+	}
+
+	Ba, Bb, err := bvp.boundaryJacobian()
+	if err != nil {
+		return nil, err
+	}
+	return matrix.Sum(matrix.Product(Ba, delta[0]), matrix.Product(Bb, delta[bvp.N-1])), nil
+}
@@ -0,0 +1,149 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+// Mattheij boundary conditions with products of end states, satisfied by the
+// exact solution x = e^t (1, 1, 1)
+func mattheijNonlinearG(xa, xb, beta matrix.MatrixRO) matrix.Matrix {
+	return matrix.MakeDenseMatrix([]float64{
+		xa.Get(0, 0)*xb.Get(0, 0) - math.E,
+		xb.Get(1, 0)*xb.Get(1, 0) - math.E*math.E,
+		0.8415*xb.Get(0, 0) + 0.5403*xb.Get(2, 0) - 1.3818*math.E,
+	}, 3, 1)
+}
+
+func mattheijNonlinearDg(xa, xb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix) {
+	Ba = matrix.MakeDenseMatrix([]float64{
+		xb.Get(0, 0), 0, 0,
+		0, 0, 0,
+		0, 0, 0,
+	}, 3, 3)
+	Bb = matrix.MakeDenseMatrix([]float64{
+		xa.Get(0, 0), 0, 0,
+		0, 2 * xb.Get(1, 0), 0,
+		0.8415, 0, 0.5403,
+	}, 3, 3)
+	return
+}
+
+func TestNonlinearBoundaryCondition(t *testing.T) {
+	conditions := []struct {
+		name string
+		bc   BoundaryCondition
+	}{
+		{"analytic", NewNonlinearBoundaryCondition(mattheijNonlinearG, mattheijNonlinearDg, 3)},
+		{"difference", NewNonlinearBoundaryCondition(mattheijNonlinearG, nil, 3)},
+	}
+
+	for _, c := range conditions {
+		n := 101
+		timeMesh := make([]float64, n, n)
+		initialGuess := make([]matrix.Matrix, n, n)
+
+		for i := 0; i < n; i++ {
+			timeMesh[i] = float64(i) / (float64(n) - 1)
+			initialGuess[i] = matrix.Scaled(matrix.Ones(3, 1), 0.9*math.Exp(timeMesh[i]))
+		}
+
+		beta := matrix.MakeDenseMatrix([]float64{19, 2}, 2, 1)
+
+		MattheijBVP, err := NewBVPWithBoundaryCondition(MattheijODE, initialGuess, timeMesh, c.bc, beta)
+
+		if err != nil {
+			t.Errorf("%s: error creating BVP: %s", c.name, err)
+			continue
+		}
+
+		opts := DefaultSolveOptions()
+		opts.AbsTol = 1e-10
+
+		_, err = MattheijBVP.SolveWithOptions(opts)
+
+		if err != nil {
+			t.Errorf("%s: error solving BVP: %s", c.name, err)
+		}
+
+		if e := mattheijMaxError(&MattheijBVP); e > 1e-3 {
+			t.Errorf("%s: error too large, got %g", c.name, e)
+		}
+
+		g, _ := MattheijBVP.boundaryResidual()
+		if math.Sqrt(sumOfSquares([]*matrix.DenseMatrix{g})) > 1e-8 {
+			t.Errorf("%s: boundary conditions not satisfied", c.name)
+		}
+
+		check, err := CheckConstraintJacobian(&MattheijBVP, 1e-6)
+
+		if err != nil || !check.Pass {
+			t.Errorf("%s: boundary Jacobian check failed", c.name)
+		}
+	}
+}
+
+func TestLinearBoundaryConditionMatchesB0B1(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(21)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	explicit, err := newMattheijBVP(21)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	explicit.BC = LinearBoundaryCondition{explicit.B0, explicit.B1, explicit.B}
+
+	for _, bvp := range []*BVP{&MattheijBVP, &explicit} {
+		for i := range bvp.X {
+			bvp.X[i] = matrix.Zeros(3, 1)
+		}
+
+		err = bvp.Solve()
+
+		if err != nil {
+			t.Errorf("Error solving BVP: %s", err)
+		}
+	}
+
+	for i := range MattheijBVP.X {
+		if !matrix.ApproxEquals(MattheijBVP.X[i], explicit.X[i], 1e-12) {
+			t.Errorf("Explicit linear boundary conditions give a different solution")
+			break
+		}
+	}
+}
+
+func TestChecksBoundaryConditionDims(t *testing.T) {
+	g := func(xa, xb, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.Zeros(2, 1)
+	}
+
+	n := 11
+	timeMesh := make([]float64, n, n)
+	initialGuess := make([]matrix.Matrix, n, n)
+
+	for i := 0; i < n; i++ {
+		timeMesh[i] = float64(i) / (float64(n) - 1)
+		initialGuess[i] = matrix.Scaled(matrix.Ones(3, 1), 0.9*math.Exp(timeMesh[i]))
+	}
+
+	beta := matrix.MakeDenseMatrix([]float64{19, 2}, 2, 1)
+
+	_, err := NewBVPWithBoundaryCondition(MattheijODE, initialGuess, timeMesh, NewNonlinearBoundaryCondition(g, nil, 3), beta)
+
+	if err == nil {
+		t.Errorf("Incorrect boundary condition dimension check. Incorrect rows used.")
+	}
+
+	_, err = NewBVPWithBoundaryCondition(MattheijODE, initialGuess, timeMesh, NewNonlinearBoundaryCondition(mattheijNonlinearG, nil, 2), beta)
+
+	if err == nil {
+		t.Errorf("Incorrect boundary condition dimension check. Incorrect variables used.")
+	}
+}
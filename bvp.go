@@ -1,10 +1,14 @@
 package bvp
 
 import (
+	"context"
 	"github.com/sbroadfoot90/go.matrix"
+	"math"
 )
 
-// Boundary conditions on the ODE are B0 x(t_1) + B1 x(t_n) = b
+// Boundary conditions on the ODE are B0 x(t_1) + B1 x(t_n) = b, or
+// BC(x(t_1), x(t_n), Beta) = 0 if BC is set, or the multipoint conditions
+// if Multipoint is set
 type BVP struct {
 	ODE     ODE
 	X       []matrix.Matrix
@@ -12,6 +16,24 @@ type BVP struct {
 	B0, B1  matrix.Matrix
 	Beta, B matrix.Matrix
 	N       int
+
+	// Discretization of the ODE between mesh points, Trapezoidal if nil
+	Discretization Discretization
+
+	// Boundary conditions, LinearBoundaryCondition{B0, B1, B} if nil
+	BC BoundaryCondition
+
+	// Multipoint boundary conditions, used in place of BC if set
+	Multipoint *MultipointBoundaryCondition
+
+	// Indices of entries of Beta solved for along with X
+	FreeParameters []int
+
+	// deferred correction subtracted from each interval residual
+	correction []*matrix.DenseMatrix
+
+	// set when T is normalised to [0, 1] with an unknown length
+	freeBoundary *freeBoundary
 }
 
 func NewBVPWithInitialGuess(ode ODE, initialGuess []matrix.Matrix, timeMesh []float64, B0, B1, beta, b matrix.Matrix) (BVP, error) {
@@ -19,35 +41,71 @@ func NewBVPWithInitialGuess(ode ODE, initialGuess []matrix.Matrix, timeMesh []fl
 
 	n := len(timeMesh)
 
-	if len(initialGuess) != n {
-		return bvp, NewDimensionError("initialGuess", ode.P, n, ode.P, len(initialGuess))
-	}
-
-	for i := 0; i < n; i++ {
-		if initialGuess[i].Rows() != ode.P || initialGuess[i].Cols() != 1 {
-			return bvp, NewDimensionError("initialGuess", ode.P, 1, initialGuess[i].Rows(), initialGuess[i].Cols())
-		}
+	if err := checkInitialGuess("NewBVPWithInitialGuess", ode, initialGuess, n); err != nil {
+		return bvp, err
 	}
 
 	if B0.Rows() != ode.P || B0.Cols() != ode.P {
-		return bvp, NewDimensionError("B0", ode.P, ode.P, B0.Rows(), B0.Cols())
+		return bvp, NewDimensionErrorIn("NewBVPWithInitialGuess", "B0", ode.P, ode.P, B0.Rows(), B0.Cols())
 	}
 
 	if B1.Rows() != ode.P || B1.Cols() != ode.P {
-		return bvp, NewDimensionError("B1", ode.P, ode.P, B1.Rows(), B1.Cols())
+		return bvp, NewDimensionErrorIn("NewBVPWithInitialGuess", "B1", ode.P, ode.P, B1.Rows(), B1.Cols())
 	}
 
 	if beta.Rows() != ode.Q || beta.Cols() != 1 {
-		return bvp, NewDimensionError("beta", ode.Q, 1, beta.Rows(), beta.Cols())
+		return bvp, NewDimensionErrorIn("NewBVPWithInitialGuess", "beta", ode.Q, 1, beta.Rows(), beta.Cols())
 	}
 	if b.Rows() != ode.P || b.Cols() != 1 {
-		return bvp, NewDimensionError("b", ode.P, 1, b.Rows(), b.Cols())
+		return bvp, NewDimensionErrorIn("NewBVPWithInitialGuess", "b", ode.P, 1, b.Rows(), b.Cols())
 	}
 
-	bvp = BVP{ode, initialGuess, timeMesh, B0, B1, beta, b, n}
+	bvp = BVP{ODE: ode, X: initialGuess, T: timeMesh, B0: B0, B1: B1, Beta: beta, B: b, N: n}
 	return bvp, nil
 }
 
+// Creates a BVP with boundary conditions bc(x(t_1), x(t_n), beta) = 0. B0, B1
+// and B are left nil.
+func NewBVPWithBoundaryCondition(ode ODE, initialGuess []matrix.Matrix, timeMesh []float64, bc BoundaryCondition, beta matrix.Matrix) (BVP, error) {
+	var bvp BVP
+
+	n := len(timeMesh)
+
+	if err := checkInitialGuess("NewBVPWithBoundaryCondition", ode, initialGuess, n); err != nil {
+		return bvp, err
+	}
+
+	if beta.Rows() != ode.Q || beta.Cols() != 1 {
+		return bvp, NewDimensionErrorIn("NewBVPWithBoundaryCondition", "beta", ode.Q, 1, beta.Rows(), beta.Cols())
+	}
+
+	bvp = BVP{ODE: ode, X: initialGuess, T: timeMesh, Beta: beta, N: n, BC: bc}
+
+	// check the dimensions of bc at the initial guess
+	if _, err := bvp.boundaryResidual(); err != nil {
+		return BVP{}, err
+	}
+	if _, _, err := bvp.boundaryJacobian(); err != nil {
+		return BVP{}, err
+	}
+
+	return bvp, nil
+}
+
+// Checks the dimensions of initialGuess on behalf of the named function
+func checkInitialGuess(function string, ode ODE, initialGuess []matrix.Matrix, n int) error {
+	if len(initialGuess) != n {
+		return NewDimensionErrorIn(function, "initialGuess", ode.P, n, ode.P, len(initialGuess))
+	}
+
+	for i := 0; i < n; i++ {
+		if initialGuess[i].Rows() != ode.P || initialGuess[i].Cols() != 1 {
+			return NewDimensionErrorIn(function, "initialGuess", ode.P, 1, initialGuess[i].Rows(), initialGuess[i].Cols())
+		}
+	}
+	return nil
+}
+
 func NewBVPWithoutInitialGuess(ode ODE, timeMesh []float64, B0, B1, beta, b matrix.Matrix) (BVP, error) {
 	n := len(timeMesh)
 	initialGuess := make([]matrix.Matrix, n, n)
@@ -59,20 +117,53 @@ func NewBVPWithoutInitialGuess(ode ODE, timeMesh []float64, B0, B1, beta, b matr
 }
 
 func (bvp *BVP) Solve() error {
-	tolerance := 1e-6
-	maxiter := 500
+	_, err := bvp.SolveWithOptions(DefaultSolveOptions())
+	return err
+}
+
+// As Solve, stopping with ctx.Err() if ctx is cancelled
+func (bvp *BVP) SolveContext(ctx context.Context) error {
+	_, err := bvp.SolveWithOptionsContext(ctx, DefaultSolveOptions())
+	return err
+}
+
+// Solves the BVP by damped Newton iteration, reporting how the solve went.
+// Free parameters are updated in Beta and also reported in the result.
+func (bvp *BVP) SolveWithOptions(opts SolveOptions) (result SolveResult, err error) {
+	return bvp.SolveWithOptionsContext(context.Background(), opts)
+}
+
+// As SolveWithOptions, checking ctx between Newton iterations. If it is
+// cancelled X is left at the last accepted iterate and ctx.Err() returned.
+func (bvp *BVP) SolveWithOptionsContext(ctx context.Context, opts SolveOptions) (result SolveResult, err error) {
+	if len(bvp.FreeParameters) > 0 {
+		defer func() { result.FreeParameters = bvp.freeParameterVector() }()
+	}
 
 	constraintBlocks, err := ConstraintVectorBlocks(bvp)
 	if err != nil {
-		return err
+		return
 	}
 
 	cost := sumOfSquares(constraintBlocks) // compute cost
-	for i := 0; i < maxiter; i++ {
+	result.Cost = cost
+
+	for ; result.Iterations < opts.MaxIterations; result.Iterations++ {
+		if err := ctx.Err(); err != nil {
+			result.Reason = Cancelled
+			return result, err
+		}
+
 		delta, err := getDelta(bvp)
+		if err != nil {
+			return result, err
+		}
 
-		if !exceedsTolerance(delta, tolerance) {
-			return nil
+		result.StepNorm = math.Sqrt(sumOfSquares(delta))
+
+		if !exceedsTolerance(delta, bvp.unknowns(), opts.AbsTol, opts.RelTol) {
+			result.Reason = Converged
+			return result, nil
 		}
 
 		// save old values
@@ -82,19 +173,22 @@ func (bvp *BVP) Solve() error {
 		for i := 0; i < bvp.N; i++ {
 			xold[i] = matrix.MakeDenseCopy(bvp.X[i])
 		}
-		var alpha float64 = 1
+		betaold := matrix.MakeDenseCopy(bvp.Beta)
 
 		for i := 0; i < bvp.N; i++ {
 			bvp.X[i].Subtract(delta[i]) // update x
 		}
+		bvp.stepFreeParameters(betaold, delta, 1)
 
 		constraintBlocks, err = ConstraintVectorBlocks(bvp)
 		if err != nil {
-			return err
+			return result, err
 		}
-		cost := sumOfSquares(constraintBlocks) // compute cost
+		cost = sumOfSquares(constraintBlocks) // compute cost
 
 		if cost < costold {
+			result.Cost = cost
+			opts.report(result)
 			continue
 		}
 
@@ -102,54 +196,66 @@ func (bvp *BVP) Solve() error {
 		for i := 0; i < bvp.N; i++ {
 			bvp.X[i] = matrix.MakeDenseCopy(xold[i])
 		}
+		bvp.stepFreeParameters(betaold, delta, 0)
 
 		var dcost float64 = 0
 		constraintBlocks, err = ConstraintVectorBlocks(bvp)
-		A, B, err := ConstraintMatrixBlocks(bvp)
+		if err != nil {
+			return result, err
+		}
+		product, err := newtonProduct(bvp, delta)
+		if err != nil {
+			return result, err
+		}
 
 		for i := 0; i < bvp.N; i++ {
-			var tempMatrix *matrix.DenseMatrix
-			if i < bvp.N-1 {
-				tempMatrix = matrix.Sum(matrix.Product(A[i], delta[i]), matrix.Product(B[i], delta[i+1]))
-			} else {
-				tempMatrix = matrix.Sum(matrix.Product(bvp.B0, delta[0]), matrix.Product(bvp.B1, delta[bvp.N-1]))
-			}
-			for j := 0; j < bvp.ODE.P; j++ {
-				dcost -= constraintBlocks[i].Get(j, 0) * tempMatrix.Get(j, 0)
+			for j := 0; j < product[i].Rows(); j++ {
+				dcost -= constraintBlocks[i].Get(j, 0) * product[i].Get(j, 0)
 			}
 		}
 
-		for {
-			if dcost > 0 {
-				// display('warning, dcost positive')
-				// display(dcost)
-				alpha = -alpha
-				dcost = -dcost
-			} else {
-				// scale delta if cost increased
-				alpha = alpha / 2
-				dcost = dcost / 2
+		var alpha float64 = 1
+		if dcost > 0 {
+			// delta is not a descent direction, search backwards
+			alpha = -alpha
+		}
+
+		accepted := false
+		for k := 0; k < opts.MaxLineSearch; k++ {
+			// scale delta since cost increased
+			alpha = alpha / 2
+			result.Halvings++
+
+			for i := range delta {
+				delta[i].Scale(0.5)
 			}
+			result.StepNorm = result.StepNorm / 2
 
-			for i := 0; i < bvp.N; i++ {
-				delta[i].Scale(alpha)
+			if math.Abs(alpha) < opts.MinStepFactor {
+				result.Cost = costold
+				result.Reason = StepTooSmall
+				return result, NonConvergenceError{"BVP solver line search step too small", result.Iterations, result.Cost, result.StepNorm}
 			}
 
-			if !exceedsTolerance(delta, tolerance) {
-				return nil // converged
+			if !exceedsTolerance(delta, bvp.unknowns(), opts.AbsTol, opts.RelTol) {
+				result.Cost = costold
+				result.Reason = Stalled
+				return result, nil
 			}
 
 			for i := 0; i < bvp.N; i++ {
-				bvp.X[i].Subtract(delta[i]) // update x
+				bvp.X[i] = matrix.Difference(xold[i], matrix.Scaled(delta[i], sign(alpha))) // update x
 			}
+			bvp.stepFreeParameters(betaold, delta, sign(alpha))
 
 			constraintBlocks, err = ConstraintVectorBlocks(bvp)
 			if err != nil {
-				return err
+				return result, err
 			}
-			cost := sumOfSquares(constraintBlocks) // compute cost
+			cost = sumOfSquares(constraintBlocks) // compute cost
 
 			if cost < costold {
+				accepted = true
 				break
 			}
 
@@ -157,20 +263,43 @@ func (bvp *BVP) Solve() error {
 			for i := 0; i < bvp.N; i++ {
 				bvp.X[i] = matrix.MakeDenseCopy(xold[i])
 			}
+			bvp.stepFreeParameters(betaold, delta, 0)
+		}
+
+		if !accepted {
+			result.Cost = costold
+			result.Reason = LineSearchFailed
+			return result, NonConvergenceError{"BVP solver line search failed", result.Iterations, result.Cost, result.StepNorm}
 		}
+		result.Cost = cost
+		opts.report(result)
 	}
 
-	return ConvergeError("Warning: BVP solver did not terminate")
+	result.Reason = MaxIterations
+	return result, NonConvergenceError{"Warning: BVP solver did not terminate", result.Iterations, result.Cost, result.StepNorm}
 }
 
+// Solves the initial value problem x(T[0]) = initialGuess by the trapezoidal
+// rule on T, without error control. SolveIVPWithOptions integrates with an
+// adaptive Runge-Kutta method instead.
 func (bvp *BVP) SolveIVP(initialGuess matrix.Matrix) error {
+	return bvp.SolveIVPContext(context.Background(), initialGuess)
+}
+
+// As SolveIVP, checking ctx between mesh points. If it is cancelled X is
+// filled up to the last completed mesh point and ctx.Err() returned.
+func (bvp *BVP) SolveIVPContext(ctx context.Context, initialGuess matrix.Matrix) error {
 	bvp.X[0] = initialGuess
 	niter := 10
 
 	for i := 1; i < len(bvp.X); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		fBefore, err := bvp.ODE.F(bvp.X[i-1], bvp.T[i-1], bvp.Beta)
 		if err != nil {
-			return err
+			return evaluationError("F", i-1, bvp.T[i-1], err)
 		}
 		dt := bvp.T[i] - bvp.T[i-1]
 		bvp.X[i] = matrix.Sum(bvp.X[i-1], matrix.Scaled(fBefore, dt))
@@ -178,17 +307,18 @@ func (bvp *BVP) SolveIVP(initialGuess matrix.Matrix) error {
 		for j := 0; j < niter; j++ {
 			fNow, err := bvp.ODE.F(bvp.X[i], bvp.T[i], bvp.Beta)
 			if err != nil {
-				return err
+				return evaluationError("F", i, bvp.T[i], err)
 			}
 			dfdxNow, err := bvp.ODE.Dfdx(bvp.X[i], bvp.T[i], bvp.Beta)
 			if err != nil {
-				return err
+				return evaluationError("Dfdx", i, bvp.T[i], err)
 			}
 			C := matrix.Difference(matrix.Eye(bvp.ODE.P), matrix.Scaled(dfdxNow, dt/2))
 			c := matrix.Difference(matrix.Difference(bvp.X[i], bvp.X[i-1]), matrix.Scaled(matrix.Sum(fNow, fBefore), dt/2))
+			// the LU solve divides by a zero pivot rather than failing
 			delta, err := C.Solve(c)
-			if err != nil {
-				return err
+			if err != nil || !isFinite(delta) {
+				return SingularSystemError{i}
 			}
 			bvp.X[i].Subtract(delta)
 		}
@@ -197,35 +327,26 @@ func (bvp *BVP) SolveIVP(initialGuess matrix.Matrix) error {
 	return nil
 }
 
+// Returns the discretization used between mesh points
+func (bvp *BVP) discretization() Discretization {
+	if bvp.Discretization == nil {
+		return Trapezoidal{}
+	}
+	return bvp.Discretization
+}
+
 func ConstraintMatrixBlocks(bvp *BVP) (A, B []*matrix.DenseMatrix, err error) {
 	A = make([]*matrix.DenseMatrix, bvp.N-1, bvp.N-1)
 	B = make([]*matrix.DenseMatrix, bvp.N-1, bvp.N-1)
 
-	dfdxNow, err := bvp.ODE.Dfdx(bvp.X[0], bvp.T[0], bvp.Beta)
-
-	if err != nil {
-		return
-	}
+	disc := bvp.discretization()
 
 	for i := 1; i < bvp.N; i++ {
-
-		dfdxBefore := matrix.MakeDenseCopy(dfdxNow)
-
-		dfdxNow, err = bvp.ODE.Dfdx(bvp.X[i], bvp.T[i], bvp.Beta)
+		A[i-1], B[i-1], err = disc.Jacobian(&bvp.ODE, bvp.X[i-1], bvp.X[i], bvp.T[i-1], bvp.T[i], bvp.Beta)
 
 		if err != nil {
-			return
+			return nil, nil, evaluationError("Dfdx", i-1, bvp.T[i-1], err)
 		}
-
-		A[i-1] = matrix.Difference(
-			matrix.Scaled(matrix.Eye(bvp.ODE.P), -1),
-			matrix.Scaled(dfdxBefore, (bvp.T[i]-bvp.T[i-1])/2),
-		)
-
-		B[i-1] = matrix.Difference(
-			matrix.Eye(bvp.ODE.P),
-			matrix.Scaled(dfdxBefore, (bvp.T[i]-bvp.T[i-1])/2),
-		)
 	}
 	return
 }
@@ -234,79 +355,89 @@ func ConstraintVectorBlocks(bvp *BVP) (constraint []*matrix.DenseMatrix, err err
 
 	constraint = make([]*matrix.DenseMatrix, bvp.N, bvp.N)
 
-	fNow, err := bvp.ODE.F(bvp.X[0], bvp.T[0], bvp.Beta)
-
-	if err != nil {
-		return
-	}
+	disc := bvp.discretization()
 
 	for i := 1; i < bvp.N; i++ {
-
-		fBefore := matrix.MakeDenseCopy(fNow)
-
-		fNow, err = bvp.ODE.F(bvp.X[i], bvp.T[i], bvp.Beta)
+		constraint[i-1], err = disc.Residual(&bvp.ODE, bvp.X[i-1], bvp.X[i], bvp.T[i-1], bvp.T[i], bvp.Beta)
 
 		if err != nil {
-			return
+			return nil, evaluationError("F", i-1, bvp.T[i-1], err)
 		}
 
-		constraint[i-1] = matrix.Difference(
-			matrix.Difference(bvp.X[i], bvp.X[i-1]),
-			matrix.Scaled(matrix.Sum(fBefore, fNow), (bvp.T[i]-bvp.T[i-1])/2),
-		)
+		if bvp.correction != nil {
+			constraint[i-1].Subtract(bvp.correction[i-1])
+		}
 	}
 
-	constraint[bvp.N-1] = matrix.Difference(
-		matrix.Sum(matrix.Product(bvp.B0, bvp.X[0]), matrix.Product(bvp.B1, bvp.X[bvp.N-1])),
-		bvp.B,
-	)
+	constraint[bvp.N-1], err = bvp.boundaryResidual()
 
 	return
 }
 
-func getDelta(bvp *BVP) (delta []*matrix.DenseMatrix, err error) {
-	// delta = solve(C(x), c(x))
-	// C(x) %*% delta = c(x)
-
+// Applies the Newton matrix to the step delta, giving one block per interval
+// followed by the linearised boundary conditions
+func newtonProduct(bvp *BVP, delta []*matrix.DenseMatrix) (product []*matrix.DenseMatrix, err error) {
 	A, B, err := ConstraintMatrixBlocks(bvp)
 	if err != nil {
 		return
 	}
 
-	c, err := ConstraintVectorBlocks(bvp)
+	product = make([]*matrix.DenseMatrix, bvp.N)
+	for i := 0; i < bvp.N-1; i++ {
+		product[i] = matrix.Sum(matrix.Product(A[i], delta[i]), matrix.Product(B[i], delta[i+1]))
+	}
+
+	product[bvp.N-1], err = bvp.boundaryProduct(delta)
 	if err != nil {
 		return
 	}
 
-	C, D, U := rightOrthogonalFactorisation(A, B, bvp.N, bvp.ODE.P)
-	rqTransformation(A, B, U, c, bvp.N, bvp.ODE.P)
-
-	hgb1b0 := matrix.Zeros(bvp.ODE.P*2, bvp.ODE.P*2)
-	hgb1b0.SetMatrix(0, 0, B[bvp.N-2])
-	hgb1b0.SetMatrix(0, bvp.ODE.P, D[bvp.N-2])
-	hgb1b0.SetMatrix(bvp.ODE.P, 0, matrix.MakeDenseCopy(bvp.B1))
-	hgb1b0.SetMatrix(bvp.ODE.P, bvp.ODE.P, matrix.MakeDenseCopy(bvp.B0))
-	smallc := matrix.Zeros(bvp.ODE.P*2, 1)
-	smallc.SetMatrix(0, 0, c[bvp.N-2])
-	smallc.SetMatrix(bvp.ODE.P, 0, c[bvp.N-1])
+	if len(bvp.FreeParameters) > 0 {
+		E, err := parameterBlocks(bvp, bvp.FreeParameters)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < bvp.N-1; i++ {
+			product[i].Add(matrix.Product(E[i], delta[bvp.N]))
+		}
 
-	deltaends, err := hgb1b0.SolveDense(smallc)
+		G, err := boundaryParameterJacobian(bvp, bvp.FreeParameters)
+		if err != nil {
+			return nil, err
+		}
+		product[bvp.N-1].Add(matrix.Product(G, delta[bvp.N]))
+	}
+	return
+}
 
-	delta = make([]*matrix.DenseMatrix, bvp.N, bvp.N)
+// Returns the Newton step, one block per mesh point followed by a block for
+// the free parameters if there are any
+func getDelta(bvp *BVP) (delta []*matrix.DenseMatrix, err error) {
+	// delta = solve(C(x), c(x))
+	// C(x) %*% delta = c(x)
 
-	for i := 0; i < bvp.N; i++ {
-		delta[i] = matrix.Zeros(bvp.ODE.P, 1)
+	f, err := factorise(bvp)
+	if err != nil {
+		return
 	}
 
-	delta[0].SetMatrix(0, 0, deltaends.GetMatrix(bvp.ODE.P, 0, bvp.ODE.P, 1))
-	delta[bvp.N-1].SetMatrix(0, 0, deltaends.GetMatrix(0, 0, bvp.ODE.P, 1))
-
-	rightBackSubstitute(B, C, D, U, c, delta, bvp.N, bvp.ODE.P)
+	c, err := ConstraintVectorBlocks(bvp)
+	if err != nil {
+		return
+	}
 
-	return
+	return f.solve(c)
 }
 
-func SetOptimalBoundaryMatrices(bvp *BVP) {
+// Replaces B0, B1 and B with boundary matrices well conditioned for the
+// current solution. These are only used when BC is nil. Also returns the
+// dichotomy of the linearised ODE, whose Growing and Decaying counts are the
+// numbers of conditions that should be imposed at T[N-1] and T[0].
+func SetOptimalBoundaryMatrices(bvp *BVP) (dichotomy Dichotomy, err error) {
+	dichotomy, err = bvp.Dichotomy()
+	if err != nil {
+		return
+	}
 
 	A, B, err := ConstraintMatrixBlocks(bvp)
 	if err != nil {
@@ -315,7 +446,12 @@ func SetOptimalBoundaryMatrices(bvp *BVP) {
 
 	_, D, _ := rightOrthogonalFactorisation(A, B, bvp.N, bvp.ODE.P)
 
-	bvp.B0, bvp.B1 = calculateBoundaryMatrices(B, D, bvp.N, bvp.ODE.P)
+	B0, B1 := calculateBoundaryMatrices(B, D, bvp.N, bvp.ODE.P)
+	if !isFinite(B0) || !isFinite(B1) {
+		return dichotomy, MatrixError("Optimal boundary matrices are not finite, the constraint matrix is rank deficient")
+	}
 
+	bvp.B0, bvp.B1 = B0, B1
 	bvp.B = matrix.Sum(matrix.Product(bvp.B0, bvp.X[0]), matrix.Product(bvp.B1, bvp.X[bvp.N-1]))
+	return
 }
@@ -1,6 +1,8 @@
 package bvp
 
 import (
+	"context"
+	"errors"
 	"github.com/sbroadfoot90/go.matrix"
 	"math"
 	"testing"
@@ -356,7 +358,9 @@ func TestMattheijBCs(t *testing.T) {
 		t.Errorf("Error creating Mattheij BVP")
 	}
 
-	SetOptimalBoundaryMatrices(&MattheijBVP)
+	if _, err := SetOptimalBoundaryMatrices(&MattheijBVP); err != nil {
+		t.Errorf("Error setting boundary matrices: %s", err)
+	}
 
 	// WriteMatrix(MattheijBVP.B0, "B0.csv")
 	// WriteMatrix(MattheijBVP.B1, "B1.csv")
@@ -441,3 +445,49 @@ func TestSolveLorenzBVP(t *testing.T) {
 
 	// WriteMatrices(LorenzBVP.X, "xlorenz.csv")
 }
+
+func TestSolveIVPContextCancelled(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(11, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+	x := matrix.MakeDenseCopy(bvp.X[5])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := bvp.SolveIVPContext(ctx, matrix.MakeDenseMatrix([]float64{0, 2}, 2, 1)); err != context.Canceled {
+		t.Errorf("Expected cancellation, got %v", err)
+	}
+
+	if !matrix.Equals(bvp.X[5], x) {
+		t.Errorf("Expected X beyond the initial point to be unchanged")
+	}
+}
+
+func TestSolveIVPSingular(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(11, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	// x' = 20 x makes I - (h/2) dfdx zero for h = 0.1
+	bvp.ODE = NewODE(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.Scaled(x, 20)
+		},
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.Scaled(matrix.Eye(2), 20)
+		},
+		2, 1,
+	)
+
+	err = bvp.SolveIVP(matrix.Ones(2, 1))
+
+	var singErr SingularSystemError
+	if !errors.As(err, &singErr) || singErr.Index != 1 {
+		t.Errorf("Expected a SingularSystemError at mesh index 1, got %v", err)
+	}
+}
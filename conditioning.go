@@ -0,0 +1,312 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"math/rand"
+)
+
+// number of random right hand sides used by Conditioning
+const conditioningSamples = 4
+
+// Report of Conditioning
+type ConditioningReport struct {
+	// Estimate of the stability constant ||J^-1|| of the Newton matrix J of
+	// the discrete BVP in the maximum norm, by the estimator of Hager and
+	// Higham. It is a lower bound, and is usually within a factor of 3.
+	StabilityConstant float64
+
+	// Largest response max_i |delta x_i| to a unit change in one boundary
+	// condition, measuring how well the boundary conditions fix the solution
+	BoundaryConstant float64
+
+	// Largest response to random unit changes in the interval residuals,
+	// measuring the dichotomy of the ODE itself
+	GreenConstant float64
+
+	// 1-norm condition number of the end system left by the orthogonal
+	// factorisation, coupling x(t_1) and x(t_N) through the boundary
+	// conditions. Infinite if it is singular.
+	EndSystem float64
+
+	Solves int // number of solves with the factorised Newton matrix
+}
+
+// Estimates the conditioning of the Newton matrix at the current X by
+// solving with the existing factorisation and its transpose. X is not
+// changed.
+func (bvp *BVP) Conditioning() (report ConditioningReport, err error) {
+	f, err := factorise(bvp)
+	if err != nil {
+		return
+	}
+
+	n, m := bvp.N, bvp.ODE.P
+	rows := m + len(bvp.FreeParameters)
+
+	// returns the maximum norm of the response to c
+	response := func(c []*matrix.DenseMatrix) (float64, error) {
+		delta, err := f.solve(c)
+		if err != nil {
+			return 0, err
+		}
+		report.Solves++
+		return maxNorm(delta), nil
+	}
+
+	zeros := func() []*matrix.DenseMatrix {
+		c := make([]*matrix.DenseMatrix, n)
+		for i := 0; i < n-1; i++ {
+			c[i] = matrix.Zeros(m, 1)
+		}
+		c[n-1] = matrix.Zeros(rows, 1)
+		return c
+	}
+
+	for j := 0; j < rows; j++ {
+		c := zeros()
+		c[n-1].Set(j, 0, 1)
+
+		r, err := response(c)
+		if err != nil {
+			return report, err
+		}
+		report.BoundaryConstant = math.Max(report.BoundaryConstant, r)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for k := 0; k < conditioningSamples; k++ {
+		c := zeros()
+		for i := 0; i < n-1; i++ {
+			for j := 0; j < m; j++ {
+				c[i].Set(j, 0, float64(2*random.Intn(2)-1))
+			}
+		}
+
+		r, err := response(c)
+		if err != nil {
+			return report, err
+		}
+		report.GreenConstant = math.Max(report.GreenConstant, r)
+	}
+
+	estimate, solves, err := f.inverseNormEstimate()
+	report.Solves += solves
+	if err != nil {
+		return
+	}
+
+	// each constant is a lower bound on ||J^-1||
+	report.StabilityConstant = math.Max(estimate, math.Max(report.BoundaryConstant, report.GreenConstant))
+	report.EndSystem = conditionNumber(f.ends)
+	return
+}
+
+// Estimates ||J^-1|| in the maximum norm, which is the 1-norm of J^-T, by
+// Higham's refinement of Hager's method (Higham, ACM TOMS 14, 1988). This
+// takes at most 11 solves with J or J^T.
+func (f *factorisation) inverseNormEstimate() (estimate float64, solves int, err error) {
+	n, m, r := f.n, f.m, f.r
+
+	// J^-T maps the columns of J, the mesh points and free parameters, to
+	// its rows, the intervals and boundary conditions
+	rows, columns := make([]int, n), make([]int, n)
+	for i := range rows {
+		rows[i], columns[i] = m, m
+	}
+	rows[n-1] = m + r
+	if r > 0 {
+		columns = append(columns, r)
+	}
+	size := n*m + r
+
+	inverseT := func(x []*matrix.DenseMatrix) ([]*matrix.DenseMatrix, error) {
+		solves++
+		return f.solveTranspose(x)
+	}
+	inverse := func(x []*matrix.DenseMatrix) ([]*matrix.DenseMatrix, error) {
+		solves++
+		return f.solve(x)
+	}
+
+	x := constantBlocks(columns, 1/float64(size))
+	y, err := inverseT(x)
+	if err != nil {
+		return
+	}
+	estimate = sumNorm(y)
+
+	xi := signBlocks(y)
+	z, err := inverse(copyBlocks(xi))
+	if err != nil {
+		return
+	}
+
+	for k := 2; k <= 5; k++ {
+		j, zj := maxEntry(z)
+		if zj <= dotBlocks(z, x) {
+			break
+		}
+
+		x = constantBlocks(columns, 0)
+		setEntry(x, j, 1)
+
+		y, err = inverseT(x)
+		if err != nil {
+			return
+		}
+
+		previous := xi
+		xi = signBlocks(y)
+		if sumNorm(y) <= estimate || equalBlocks(xi, previous) {
+			estimate = math.Max(estimate, sumNorm(y))
+			break
+		}
+		estimate = sumNorm(y)
+
+		z, err = inverse(copyBlocks(xi))
+		if err != nil {
+			return
+		}
+	}
+
+	// guards against x missing the large entries of J^-T
+	x = constantBlocks(columns, 0)
+	for j := 0; j < size; j++ {
+		v := 1 + float64(j)/math.Max(float64(size-1), 1)
+		if j%2 == 1 {
+			v = -v
+		}
+		setEntry(x, j, v)
+	}
+	y, err = inverseT(x)
+	if err != nil {
+		return
+	}
+	estimate = math.Max(estimate, 2*sumNorm(y)/float64(3*size))
+	return
+}
+
+// Returns the largest absolute entry over all the blocks
+func maxNorm(blocks []*matrix.DenseMatrix) (norm float64) {
+	for _, b := range blocks {
+		for i := 0; i < b.Rows(); i++ {
+			for j := 0; j < b.Cols(); j++ {
+				norm = math.Max(norm, math.Abs(b.Get(i, j)))
+			}
+		}
+	}
+	return
+}
+
+// Returns blocks of the given sizes with every entry v
+func constantBlocks(sizes []int, v float64) []*matrix.DenseMatrix {
+	blocks := make([]*matrix.DenseMatrix, len(sizes))
+	for i, size := range sizes {
+		blocks[i] = matrix.Zeros(size, 1)
+		for j := 0; j < size; j++ {
+			blocks[i].Set(j, 0, v)
+		}
+	}
+	return blocks
+}
+
+func copyBlocks(blocks []*matrix.DenseMatrix) []*matrix.DenseMatrix {
+	c := make([]*matrix.DenseMatrix, len(blocks))
+	for i, b := range blocks {
+		c[i] = matrix.MakeDenseCopy(b)
+	}
+	return c
+}
+
+// Sets entry k of the blocks taken as one vector
+func setEntry(blocks []*matrix.DenseMatrix, k int, v float64) {
+	for _, b := range blocks {
+		if k < b.Rows() {
+			b.Set(k, 0, v)
+			return
+		}
+		k -= b.Rows()
+	}
+}
+
+// Returns the index and absolute value of the largest entry of the blocks
+// taken as one vector
+func maxEntry(blocks []*matrix.DenseMatrix) (index int, max float64) {
+	k := 0
+	for _, b := range blocks {
+		for i := 0; i < b.Rows(); i++ {
+			if v := math.Abs(b.Get(i, 0)); v > max {
+				index, max = k, v
+			}
+			k++
+		}
+	}
+	return
+}
+
+// Returns the sum of the absolute entries over all the blocks
+func sumNorm(blocks []*matrix.DenseMatrix) (norm float64) {
+	for _, b := range blocks {
+		for i := 0; i < b.Rows(); i++ {
+			norm += math.Abs(b.Get(i, 0))
+		}
+	}
+	return
+}
+
+func dotBlocks(a, b []*matrix.DenseMatrix) (dot float64) {
+	for i := range a {
+		for j := 0; j < a[i].Rows(); j++ {
+			dot += a[i].Get(j, 0) * b[i].Get(j, 0)
+		}
+	}
+	return
+}
+
+// Returns the signs of the entries, taking the sign of 0 as 1
+func signBlocks(blocks []*matrix.DenseMatrix) []*matrix.DenseMatrix {
+	signs := make([]*matrix.DenseMatrix, len(blocks))
+	for i, b := range blocks {
+		signs[i] = matrix.Zeros(b.Rows(), 1)
+		for j := 0; j < b.Rows(); j++ {
+			if b.Get(j, 0) < 0 {
+				signs[i].Set(j, 0, -1)
+			} else {
+				signs[i].Set(j, 0, 1)
+			}
+		}
+	}
+	return signs
+}
+
+func equalBlocks(a, b []*matrix.DenseMatrix) bool {
+	for i := range a {
+		if !matrix.Equals(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the maximum absolute column sum of A
+func oneNorm(A matrix.MatrixRO) (norm float64) {
+	for j := 0; j < A.Cols(); j++ {
+		var sum float64
+		for i := 0; i < A.Rows(); i++ {
+			sum += math.Abs(A.Get(i, j))
+		}
+		norm = math.Max(norm, sum)
+	}
+	return
+}
+
+// Returns the 1-norm condition number of a square matrix, or +Inf if it is
+// singular
+func conditionNumber(A *matrix.DenseMatrix) float64 {
+	inverse, err := A.Inverse()
+	if err != nil {
+		return math.Inf(1)
+	}
+	return oneNorm(A) * oneNorm(inverse)
+}
@@ -0,0 +1,188 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestConditioning(t *testing.T) {
+	well, err := newOscillatorEndsBVP(101, 2)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	ill, err := newOscillatorEndsBVP(101, 3.1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	x := matrix.MakeDenseCopy(well.X[50])
+
+	wellReport, err := well.Conditioning()
+	if err != nil {
+		t.Errorf("Error estimating conditioning: %s", err)
+		return
+	}
+
+	illReport, err := ill.Conditioning()
+	if err != nil {
+		t.Errorf("Error estimating conditioning: %s", err)
+		return
+	}
+
+	if !matrix.Equals(x, well.X[50]) {
+		t.Errorf("Conditioning changed X")
+	}
+
+	// the response of y'(0) to a unit change in y(tf) is 1/sin(tf)
+	if r := wellReport.BoundaryConstant; r < 0.9/math.Sin(2) || r > 1.1/math.Sin(2) {
+		t.Errorf("Boundary constant %f, expected about %f", r, 1/math.Sin(2))
+	}
+
+	if illReport.StabilityConstant < 10*wellReport.StabilityConstant {
+		t.Errorf("Expected a much larger stability constant near tf = pi, got %f and %f", illReport.StabilityConstant, wellReport.StabilityConstant)
+	}
+
+	if illReport.EndSystem < 10*wellReport.EndSystem {
+		t.Errorf("Expected a worse conditioned end system near tf = pi, got %g and %g", illReport.EndSystem, wellReport.EndSystem)
+	}
+
+	// the norm estimate takes between 3 and 11 solves
+	if s := wellReport.Solves - 2 - conditioningSamples; s < 3 || s > 11 {
+		t.Errorf("Expected %d to %d solves, got %d", 5+conditioningSamples, 13+conditioningSamples, wellReport.Solves)
+	}
+}
+
+func TestStabilityConstant(t *testing.T) {
+	mattheij, err := newMattheijBVP(21)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP: %s", err)
+		return
+	}
+
+	ill, err := newOscillatorEndsBVP(21, 3.1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	for _, bvp := range []*BVP{&mattheij, &ill} {
+		report, err := bvp.Conditioning()
+		if err != nil {
+			t.Errorf("Error estimating conditioning: %s", err)
+			continue
+		}
+
+		f, err := factorise(bvp)
+		if err != nil {
+			t.Errorf("Error factorising: %s", err)
+			continue
+		}
+
+		// ||J^-1|| is the largest 1-norm of a row J^-T e_k of J^-1
+		columns := make([]int, bvp.N)
+		for i := range columns {
+			columns[i] = bvp.ODE.P
+		}
+		var exact float64
+		for k := 0; k < bvp.N*bvp.ODE.P; k++ {
+			row, err := f.solveTranspose(unitBlocks(columns, k))
+			if err != nil {
+				t.Errorf("Error solving with the transpose: %s", err)
+				return
+			}
+			exact = math.Max(exact, sumNorm(row))
+		}
+
+		if report.StabilityConstant > exact*(1+1e-10) || report.StabilityConstant < exact/3 {
+			t.Errorf("Stability constant %g is not close to ||J^-1|| = %g", report.StabilityConstant, exact)
+		}
+	}
+}
+
+// Returns blocks of zeros of the given sizes with a 1 at entry k overall
+func unitBlocks(sizes []int, k int) []*matrix.DenseMatrix {
+	blocks := make([]*matrix.DenseMatrix, len(sizes))
+	for i, size := range sizes {
+		blocks[i] = matrix.Zeros(size, 1)
+		if 0 <= k && k < size {
+			blocks[i].Set(k, 0, 1)
+		}
+		k -= size
+	}
+	return blocks
+}
+
+func TestSolveTranspose(t *testing.T) {
+	ends, err := newOscillatorEndsBVP(6, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	free, err := newSturmLiouvilleBVP(sturmLiouvilleODE, 6, 1, 0.8)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	multipoint, err := newOscillatorMultipointBVP(6, []int{0, 3})
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	for _, bvp := range []*BVP{&ends, &free, &multipoint} {
+		f, err := factorise(bvp)
+		if err != nil {
+			t.Errorf("Error factorising: %s", err)
+			continue
+		}
+
+		n, m, r := bvp.N, bvp.ODE.P, len(bvp.FreeParameters)
+
+		// rows of J are the intervals and the boundary conditions, and its
+		// columns the mesh points and the free parameters
+		rows, columns := make([]int, n), make([]int, n)
+		for i := range rows {
+			rows[i], columns[i] = m, m
+		}
+		rows[n-1] = m + r
+		if r > 0 {
+			columns = append(columns, r)
+		}
+
+		// row k of J^-1 is J^-T e_k, and column l is J^-1 e_l
+		for l := 0; l < n*m+r; l++ {
+			column, err := f.solve(unitBlocks(rows, l))
+			if err != nil {
+				t.Errorf("Error solving: %s", err)
+				break
+			}
+
+			for k := 0; k < n*m+r; k++ {
+				row, err := f.solveTranspose(unitBlocks(columns, k))
+				if err != nil {
+					t.Errorf("Error solving with the transpose: %s", err)
+					return
+				}
+
+				var a, b float64
+				for i, block := range unitBlocks(columns, k) {
+					a += matrix.Product(block.Transpose(), column[i]).Get(0, 0)
+				}
+				for i, block := range unitBlocks(rows, l) {
+					b += matrix.Product(block.Transpose(), row[i]).Get(0, 0)
+				}
+
+				if math.Abs(a-b) > 1e-10*(1+math.Abs(a)) {
+					t.Errorf("Entry (%d, %d) of the inverse is %g from J and %g from its transpose", k, l, a, b)
+					return
+				}
+			}
+		}
+	}
+}
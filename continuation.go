@@ -0,0 +1,416 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+type ContinuationMethod int
+
+const (
+	// Steps the parameter and re-solves with SolveWithOptions. Fails at folds.
+	NaturalParameter ContinuationMethod = iota
+
+	// Steps along the branch in arclength, with the parameter solved for by
+	// Newton iteration on the BVP bordered with the arclength condition
+	PseudoArclength
+)
+
+type ContinuationPredictor int
+
+const (
+	// Predicts along the tangent to the branch, found from the factorised
+	// Newton matrix
+	TangentPredictor ContinuationPredictor = iota
+
+	// Predicts along the secant through the last two points
+	SecantPredictor
+)
+
+// Options for Continuation
+type ContinuationOptions struct {
+	Parameter int // index into Beta of the continuation parameter
+
+	Method    ContinuationMethod
+	Predictor ContinuationPredictor
+
+	// Initial step, in the parameter for NaturalParameter or in arclength for
+	// PseudoArclength. Its sign sets the initial direction of the parameter.
+	Step             float64
+	MinStep, MaxStep float64
+
+	// Continuation stops when the parameter leaves [Min, Max] or after
+	// MaxPoints points
+	Min, Max  float64
+	MaxPoints int
+
+	MaxCorrector int          // maximum number of Newton iterations per point
+	Solve        SolveOptions // corrector tolerances, and options for the first solve
+
+	// Monitor test functions for folds and branch points between points, and
+	// locate those found by bisection on the step size to within
+	// LocateTolerance, using at most MaxLocate corrections
+	DetectBifurcations bool
+	LocateTolerance    float64
+	MaxLocate          int
+}
+
+func DefaultContinuationOptions() ContinuationOptions {
+	return ContinuationOptions{
+		Parameter:    0,
+		Method:       PseudoArclength,
+		Predictor:    TangentPredictor,
+		Step:         0.1,
+		MinStep:      1e-6,
+		MaxStep:      1,
+		Min:          math.Inf(-1),
+		Max:          math.Inf(1),
+		MaxPoints:    100,
+		MaxCorrector: 10,
+		Solve:        DefaultSolveOptions(),
+
+		DetectBifurcations: false,
+		LocateTolerance:    1e-8,
+		MaxLocate:          50,
+	}
+}
+
+// A converged point on a solution branch
+type ContinuationPoint struct {
+	Parameter  float64
+	Norm       float64 // root mean square of X over the mesh
+	X          []matrix.Matrix
+	Iterations int     // corrector iterations taken
+	Step       float64 // step size used to reach the point
+}
+
+// Report of a call to Continuation
+type ContinuationResult struct {
+	Points []ContinuationPoint
+
+	// located folds and branch points, if DetectBifurcations is set
+	Bifurcations []BifurcationPoint
+}
+
+// Follows the branch of solutions through the current X and Beta as
+// Beta[opts.Parameter] varies. Arclength is measured with the mesh inner
+// product 1/N sum_i u_i.v_i on X plus the product of parameter changes. On
+// return X and Beta hold the last point.
+func (bvp *BVP) Continuation(opts ContinuationOptions) (result ContinuationResult, err error) {
+	if len(bvp.FreeParameters) > 0 {
+		return result, MatrixError("Continuation does not support free parameters")
+	}
+
+	p := opts.Parameter
+	if p < 0 || p >= bvp.ODE.Q {
+		return result, MatrixError("Continuation parameter is not an index into beta")
+	}
+
+	solve, err := bvp.SolveWithOptions(opts.Solve)
+	if err != nil {
+		return
+	}
+	result.Points = append(result.Points, bvp.continuationPoint(p, solve.Iterations, 0))
+
+	monitor := opts.Predictor == TangentPredictor || opts.DetectBifurcations
+
+	// tangent at the current point, initially oriented towards the
+	// increasing or decreasing parameter
+	dx := make([]*matrix.DenseMatrix, bvp.N)
+	for i := range dx {
+		dx[i] = matrix.Zeros(bvp.ODE.P, 1)
+	}
+	dl := sign(opts.Step)
+
+	var tau float64 // sign of the bordered determinant at the current point
+	if monitor {
+		dx, dl, tau, err = branchTangent(bvp, p, dx, dl)
+		if err != nil {
+			return
+		}
+	}
+
+	ds := math.Abs(opts.Step)
+
+	for len(result.Points) < opts.MaxPoints {
+		last := result.Points[len(result.Points)-1]
+		if last.Parameter < opts.Min || last.Parameter > opts.Max {
+			return
+		}
+
+		// direction of the step
+		sx, sl := dx, dl
+		if opts.Predictor == SecantPredictor && len(result.Points) > 1 {
+			sx, sl = secant(result.Points[len(result.Points)-2], last, p)
+		}
+
+		x0 := make([]*matrix.DenseMatrix, bvp.N)
+		for i := range x0 {
+			x0[i] = matrix.MakeDenseCopy(bvp.X[i])
+		}
+		beta0 := matrix.MakeDenseCopy(bvp.Beta)
+
+		for {
+			iterations, corrErr := bvp.continuationStep(opts, x0, beta0, sx, sl, ds)
+			if corrErr == nil {
+				result.Points = append(result.Points, bvp.continuationPoint(p, iterations, ds))
+				break
+			}
+
+			// only a corrector which failed to converge is retried
+			last, ok := corrErr.(NonConvergenceError)
+			if !ok {
+				return result, corrErr
+			}
+
+			// revert and retry with a shorter step
+			bvp.restoreContinuationPoint(x0, beta0)
+
+			ds /= 2
+			if ds < opts.MinStep {
+				return result, NonConvergenceError{"Continuation step size fell below MinStep", last.Iterations, last.Cost, last.StepNorm}
+			}
+		}
+
+		if monitor {
+			ndx, ndl, ntau, err := branchTangent(bvp, p, sx, sl)
+			if err != nil {
+				return result, err
+			}
+
+			if opts.DetectBifurcations {
+				var found []BifurcationType
+				if sign(ndl) != sign(dl) {
+					found = append(found, Fold)
+				}
+				if ntau != tau {
+					found = append(found, BranchPoint)
+				}
+
+				if len(found) > 0 {
+					x1 := make([]*matrix.DenseMatrix, bvp.N)
+					for i := range x1 {
+						x1[i] = matrix.MakeDenseCopy(bvp.X[i])
+					}
+					beta1 := matrix.MakeDenseCopy(bvp.Beta)
+
+					for _, kind := range found {
+						point, err := bvp.locateBifurcation(opts, kind, x0, beta0, sx, sl, ds, dl, tau)
+						if err != nil {
+							return result, err
+						}
+						point.Index = len(result.Points) - 1
+						result.Bifurcations = append(result.Bifurcations, point)
+					}
+
+					bvp.restoreContinuationPoint(x1, beta1)
+				}
+			}
+
+			dx, dl, tau = ndx, ndl, ntau
+		}
+
+		if result.Points[len(result.Points)-1].Iterations <= 3 {
+			ds = math.Min(1.5*ds, opts.MaxStep)
+		}
+	}
+	return
+}
+
+func (bvp *BVP) restoreContinuationPoint(x []*matrix.DenseMatrix, beta matrix.MatrixRO) {
+	for i := range x {
+		bvp.X[i] = matrix.MakeDenseCopy(x[i])
+	}
+	bvp.Beta = matrix.MakeDenseCopy(beta)
+}
+
+// Predicts from (x0, beta0) along (dx, dl) with step ds and corrects, leaving
+// the new point in X and Beta
+func (bvp *BVP) continuationStep(opts ContinuationOptions, x0 []*matrix.DenseMatrix, beta0 matrix.MatrixRO, dx []*matrix.DenseMatrix, dl, ds float64) (iterations int, err error) {
+	p := opts.Parameter
+	l0 := beta0.Get(p, 0)
+
+	if opts.Method == NaturalParameter {
+		if math.Abs(dl) < machineEpsilon {
+			return 0, NonConvergenceError{"Branch is vertical in the continuation parameter", 0, 0, 0}
+		}
+
+		// step the parameter by ds in the direction of travel
+		h := ds * sign(dl) / dl
+		for i := range x0 {
+			bvp.X[i] = matrix.Sum(x0[i], matrix.Scaled(dx[i], h))
+		}
+		bvp.setParameter(p, l0+ds*sign(dl))
+
+		solveOpts := opts.Solve
+		solveOpts.MaxIterations = opts.MaxCorrector
+
+		result, err := bvp.SolveWithOptions(solveOpts)
+		return result.Iterations, err
+	}
+
+	for i := range x0 {
+		bvp.X[i] = matrix.Sum(x0[i], matrix.Scaled(dx[i], ds))
+	}
+	bvp.setParameter(p, l0+ds*dl)
+
+	var cost, stepNorm float64
+	for iterations = 1; iterations <= opts.MaxCorrector; iterations++ {
+		f, err := factorise(bvp)
+		if err != nil {
+			return iterations, err
+		}
+
+		c, err := ConstraintVectorBlocks(bvp)
+		if err != nil {
+			return iterations, err
+		}
+		cost = sumOfSquares(c)
+
+		dG, err := parameterDerivative(bvp, p)
+		if err != nil {
+			return iterations, err
+		}
+
+		w1, err := f.solve(c)
+		if err != nil {
+			return iterations, err
+		}
+
+		w2, err := f.solve(dG)
+		if err != nil {
+			return iterations, err
+		}
+
+		// arclength condition <x - x0, dx> + (l - l0) dl = ds
+		l := bvp.Beta.Get(p, 0)
+		step := make([]*matrix.DenseMatrix, bvp.N)
+		for i := range step {
+			step[i] = matrix.Difference(bvp.X[i], x0[i])
+		}
+		a := meshDot(step, dx) + (l-l0)*dl - ds
+
+		// eliminate delta x = w1 - w2 delta l from the bordered system
+		deltal := (a - meshDot(dx, w1)) / (dl - meshDot(dx, w2))
+		if math.IsNaN(deltal) || math.IsInf(deltal, 0) {
+			return iterations, SingularSystemError{bvp.N - 1}
+		}
+
+		delta := make([]*matrix.DenseMatrix, bvp.N, bvp.N+1)
+		for i := range delta {
+			delta[i] = matrix.Difference(w1[i], matrix.Scaled(w2[i], deltal))
+			bvp.X[i].Subtract(delta[i])
+		}
+		bvp.setParameter(p, l-deltal)
+
+		unknowns := make([]matrix.Matrix, bvp.N, bvp.N+1)
+		copy(unknowns, bvp.X)
+		unknowns = append(unknowns, matrix.MakeDenseMatrix([]float64{l - deltal}, 1, 1))
+		delta = append(delta, matrix.MakeDenseMatrix([]float64{deltal}, 1, 1))
+		stepNorm = math.Sqrt(sumOfSquares(delta))
+
+		if !exceedsTolerance(delta, unknowns, opts.Solve.AbsTol, opts.Solve.RelTol) {
+			return iterations, nil
+		}
+	}
+
+	return opts.MaxCorrector, NonConvergenceError{"Continuation corrector did not converge", opts.MaxCorrector, cost, stepNorm}
+}
+
+// Returns the unit tangent (dx, dl) to the branch at the current point,
+// oriented to continue in the direction of (prevx, prevl), and the sign of
+// the determinant of the Newton matrix bordered by dG/dBeta[p] and the
+// tangent. The tangent solves J dx + dG/dBeta[p] dl = 0.
+func branchTangent(bvp *BVP, p int, prevx []*matrix.DenseMatrix, prevl float64) (dx []*matrix.DenseMatrix, dl, tau float64, err error) {
+	f, err := factorise(bvp)
+	if err != nil {
+		return
+	}
+
+	dG, err := parameterDerivative(bvp, p)
+	if err != nil {
+		return
+	}
+
+	w, err := f.solve(dG)
+	if err != nil {
+		return
+	}
+
+	dx = make([]*matrix.DenseMatrix, bvp.N)
+	for i := range dx {
+		dx[i] = matrix.Scaled(w[i], -1)
+	}
+	dl = 1
+
+	scale := 1 / math.Sqrt(meshDot(dx, dx)+1)
+	if meshDot(dx, prevx)+dl*prevl < 0 {
+		scale = -scale
+	}
+
+	for i := range dx {
+		dx[i].Scale(scale)
+	}
+	dl *= scale
+
+	// det [J dG; dx^T/N dl] = det(J) (dl - <dx, J^-1 dG>)
+	tau = f.determinantSign() * sign(dl-meshDot(dx, w))
+	return
+}
+
+// Returns the unit secant from point a to point b
+func secant(a, b ContinuationPoint, p int) (dx []*matrix.DenseMatrix, dl float64) {
+	dx = make([]*matrix.DenseMatrix, len(b.X))
+	for i := range dx {
+		dx[i] = matrix.Difference(b.X[i], a.X[i])
+	}
+	dl = b.Parameter - a.Parameter
+
+	scale := 1 / math.Sqrt(meshDot(dx, dx)+dl*dl)
+	for i := range dx {
+		dx[i].Scale(scale)
+	}
+	dl *= scale
+	return
+}
+
+// Returns the derivative of ConstraintVectorBlocks with respect to Beta[p]
+func parameterDerivative(bvp *BVP, p int) (dG []*matrix.DenseMatrix, err error) {
+	E, err := parameterBlocks(bvp, []int{p})
+	if err != nil {
+		return
+	}
+
+	dG = append(E, nil)
+	dG[bvp.N-1], err = boundaryParameterJacobian(bvp, []int{p})
+	return
+}
+
+func (bvp *BVP) setParameter(p int, value float64) {
+	beta := matrix.MakeDenseCopy(bvp.Beta)
+	beta.Set(p, 0, value)
+	bvp.Beta = beta
+}
+
+func (bvp *BVP) continuationPoint(p, iterations int, step float64) ContinuationPoint {
+	x := make([]matrix.Matrix, bvp.N)
+	var ss float64
+	for i := range x {
+		x[i] = matrix.MakeDenseCopy(bvp.X[i])
+		for j := 0; j < bvp.ODE.P; j++ {
+			ss += bvp.X[i].Get(j, 0) * bvp.X[i].Get(j, 0)
+		}
+	}
+
+	return ContinuationPoint{bvp.Beta.Get(p, 0), math.Sqrt(ss / float64(bvp.N)), x, iterations, step}
+}
+
+// Returns the mesh inner product 1/N sum_i u_i.v_i
+func meshDot(u, v []*matrix.DenseMatrix) (dot float64) {
+	for i := range u {
+		for j := 0; j < u[i].Rows(); j++ {
+			dot += u[i].Get(j, 0) * v[i].Get(j, 0)
+		}
+	}
+	return dot / float64(len(u))
+}
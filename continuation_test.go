@@ -0,0 +1,143 @@
+package bvp
+
+import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestNaturalParameterContinuation(t *testing.T) {
+	bvp, err := newBratuBVP(51, 0.5)
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	opts := DefaultContinuationOptions()
+	opts.Method = NaturalParameter
+	opts.Step = 0.5
+	opts.MaxStep = 0.5
+	opts.Max = 3
+
+	result, err := bvp.Continuation(opts)
+
+	if err != nil {
+		t.Errorf("Error in continuation: %s", err)
+	}
+
+	last := result.Points[len(result.Points)-1]
+	if len(result.Points) < 6 || last.Parameter < 3 {
+		t.Errorf("Continuation stopped early at lambda = %g", last.Parameter)
+	}
+
+	for k := 1; k < len(result.Points); k++ {
+		if result.Points[k].Parameter <= result.Points[k-1].Parameter || result.Points[k].Norm <= result.Points[k-1].Norm {
+			t.Errorf("Lower Bratu branch should increase in lambda and norm")
+		}
+	}
+}
+
+func TestPseudoArclengthPassesFold(t *testing.T) {
+	for _, predictor := range []ContinuationPredictor{TangentPredictor, SecantPredictor} {
+		bvp, err := newBratuBVP(51, 1.5)
+
+		if err != nil {
+			t.Errorf("Error creating BVP: %s", err)
+			return
+		}
+
+		opts := DefaultContinuationOptions()
+		opts.Predictor = predictor
+		opts.Step = 0.2
+		opts.MaxStep = 0.3
+		opts.Min = 1
+		opts.MaxPoints = 200
+
+		result, err := bvp.Continuation(opts)
+
+		if err != nil {
+			t.Errorf("Error in continuation: %s", err)
+		}
+
+		maxLambda := 0.
+		for _, point := range result.Points {
+			maxLambda = math.Max(maxLambda, point.Parameter)
+		}
+
+		if math.Abs(maxLambda-bratuFold) > 0.01 {
+			t.Errorf("Fold not passed, expected a maximum lambda near %g, got %g", bratuFold, maxLambda)
+		}
+
+		last := result.Points[len(result.Points)-1]
+		if last.Parameter > 1 {
+			t.Errorf("Continuation did not return along the upper branch, stopped at lambda = %g", last.Parameter)
+		}
+
+		// the upper branch has y(1/2) around 4 at lambda = 1
+		if last.X[25].Get(0, 0) < 3 {
+			t.Errorf("Continuation did not reach the upper branch, y(1/2) = %g", last.X[25].Get(0, 0))
+		}
+
+		c, _ := ConstraintVectorBlocks(&bvp)
+		if math.Sqrt(sumOfSquares(c)) > 1e-6 {
+			t.Errorf("Final continuation point is not a solution")
+		}
+	}
+}
+
+func TestNaturalParameterContinuationPastFold(t *testing.T) {
+	bvp, err := newBratuBVP(51, 3)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	// the lower Bratu branch turns back at lambda = 3.51
+	opts := DefaultContinuationOptions()
+	opts.Method = NaturalParameter
+	opts.Step = 0.5
+	opts.MaxStep = 0.5
+	opts.Max = 4
+
+	_, err = bvp.Continuation(opts)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) {
+		t.Errorf("Expected a NonConvergenceError at the fold, got %v", err)
+	}
+}
+
+func TestContinuationEvaluationError(t *testing.T) {
+	bvp, err := newBratuBVP(51, 0.5)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	// bratuODE whose f returns the wrong dimensions for lambda > 1.2
+	bvp.ODE = NewODE(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			if beta.Get(0, 0) > 1.2 {
+				return matrix.Zeros(3, 1)
+			}
+			return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -beta.Get(0, 0) * math.Exp(x.Get(0, 0))}, 2, 1)
+		},
+		nil, 2, 1,
+	)
+
+	opts := DefaultContinuationOptions()
+	opts.Method = NaturalParameter
+	opts.Step = 0.5
+	opts.MaxStep = 0.5
+	opts.Max = 3
+
+	result, err := bvp.Continuation(opts)
+
+	// the failure is returned at once rather than retried down to MinStep
+	var evalErr EvaluationError
+	if !errors.As(err, &evalErr) || len(result.Points) != 2 {
+		t.Errorf("Expected an EvaluationError after 2 points, got %v after %d", err, len(result.Points))
+	}
+}
@@ -0,0 +1,182 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// Options for SolveDeferredCorrection
+type DeferredCorrectionOptions struct {
+	// Number of correction sweeps after the trapezoidal solve. Sweep k raises
+	// the order of the solution to 2k+2, up to a maximum of 2 sweeps.
+	Sweeps int
+
+	Solve SolveOptions // options for each solve
+}
+
+func DefaultDeferredCorrectionOptions() DeferredCorrectionOptions {
+	return DeferredCorrectionOptions{
+		Sweeps: 2,
+		Solve:  DefaultSolveOptions(),
+	}
+}
+
+// Report of a call to SolveDeferredCorrection
+type DeferredCorrectionResult struct {
+	// Orders[k] is the order of the solution after sweep k+1 and Errors[k]
+	// estimates its error by max_j |dx_j| / (1 + |x_j|), where dx is the
+	// Newton step that replacing the correction by one of the next higher
+	// order would make.
+	Orders []int
+	Errors []float64
+
+	Solve SolveResult // report from the final solve
+}
+
+// Solves the BVP with the trapezoidal rule, then repeatedly uses the solution
+// to estimate the truncation error of the trapezoidal rule on each interval,
+// adds these corrections to ConstraintVectorBlocks and re-solves. The mesh and
+// the factorisation used by Solve are unchanged.
+func (bvp *BVP) SolveDeferredCorrection(opts DeferredCorrectionOptions) (result DeferredCorrectionResult, err error) {
+	if _, ok := bvp.discretization().(Trapezoidal); !ok {
+		return result, ArgumentError{"SolveDeferredCorrection", "bvp", "must use the trapezoidal discretization"}
+	}
+
+	if opts.Sweeps < 0 || opts.Sweeps > 2 {
+		return result, ArgumentError{"SolveDeferredCorrection", "Sweeps", "must be between 0 and 2"}
+	}
+
+	// the error estimate after the last sweep uses a stencil of 2 Sweeps + 4
+	if bvp.N < 2*opts.Sweeps+4 {
+		return result, ArgumentError{"SolveDeferredCorrection", "bvp", "has too few mesh points for the number of sweeps"}
+	}
+
+	defer func() { bvp.correction = nil }()
+
+	result.Solve, err = bvp.SolveWithOptions(opts.Solve)
+	if err != nil {
+		return
+	}
+
+	for sweep := 1; sweep <= opts.Sweeps; sweep++ {
+		order := 2*sweep + 2
+
+		bvp.correction, err = trapezoidalCorrection(bvp, order)
+		if err != nil {
+			return
+		}
+
+		result.Solve, err = bvp.SolveWithOptions(opts.Solve)
+		if err != nil {
+			return
+		}
+
+		estimate, err := bvp.correctionError(order + 2)
+		if err != nil {
+			return result, err
+		}
+
+		result.Orders = append(result.Orders, order)
+		result.Errors = append(result.Errors, estimate)
+	}
+	return
+}
+
+// Returns max_j |dx_j| / (1 + |x_j|) for the Newton step dx from the current
+// solution with the correction of the given stencil in place of the current
+// one. The correction is restored before returning.
+func (bvp *BVP) correctionError(stencil int) (estimate float64, err error) {
+	current := bvp.correction
+	defer func() { bvp.correction = current }()
+
+	bvp.correction, err = trapezoidalCorrection(bvp, stencil)
+	if err != nil {
+		return
+	}
+
+	delta, err := getDelta(bvp)
+	if err != nil {
+		return
+	}
+
+	for i := range bvp.X {
+		for j := 0; j < bvp.ODE.P; j++ {
+			estimate = math.Max(estimate, math.Abs(delta[i].Get(j, 0))/(1+math.Abs(bvp.X[i].Get(j, 0))))
+		}
+	}
+	return
+}
+
+// Estimates the truncation error of the trapezoidal rule on each interval,
+//
+//	integral of f over [t_i, t_i+1] - h/2 (f_i + f_i+1)
+//
+// where the integral is taken of the polynomial interpolating f at the
+// stencil of mesh points nearest the interval.
+func trapezoidalCorrection(bvp *BVP, stencil int) (correction []*matrix.DenseMatrix, err error) {
+	F := make([]matrix.Matrix, bvp.N)
+	for i := range bvp.X {
+		F[i], err = bvp.ODE.F(bvp.X[i], bvp.T[i], bvp.Beta)
+		if err != nil {
+			return nil, evaluationError("F", i, bvp.T[i], err)
+		}
+	}
+
+	correction = make([]*matrix.DenseMatrix, bvp.N-1)
+	for i := 0; i < bvp.N-1; i++ {
+		start := i - (stencil/2 - 1)
+		if start < 0 {
+			start = 0
+		}
+		if start > bvp.N-stencil {
+			start = bvp.N - stencil
+		}
+
+		weights := interpolatoryWeights(bvp.T[start:start+stencil], bvp.T[i], bvp.T[i+1])
+
+		h := bvp.T[i+1] - bvp.T[i]
+		correction[i] = matrix.Scaled(matrix.Sum(F[i], F[i+1]), -h/2)
+		for k, w := range weights {
+			correction[i].Add(matrix.Scaled(F[start+k], w))
+		}
+	}
+	return
+}
+
+// 4 point Gauss-Legendre quadrature on [0, 1]
+var (
+	gaussLegendre8C = []float64{
+		0.5 - math.Sqrt(3./7.+2./7.*math.Sqrt(6./5.))/2,
+		0.5 - math.Sqrt(3./7.-2./7.*math.Sqrt(6./5.))/2,
+		0.5 + math.Sqrt(3./7.-2./7.*math.Sqrt(6./5.))/2,
+		0.5 + math.Sqrt(3./7.+2./7.*math.Sqrt(6./5.))/2,
+	}
+	gaussLegendre8B = []float64{
+		(18 - math.Sqrt(30)) / 72,
+		(18 + math.Sqrt(30)) / 72,
+		(18 + math.Sqrt(30)) / 72,
+		(18 - math.Sqrt(30)) / 72,
+	}
+)
+
+// Returns weights w such that sum_k w[k] g(nodes[k]) is the integral over
+// [a, b] of the polynomial interpolating g at nodes. The Lagrange basis is
+// integrated with 4 point Gauss-Legendre quadrature, which is exact for up
+// to 8 nodes.
+func interpolatoryWeights(nodes []float64, a, b float64) []float64 {
+	weights := make([]float64, len(nodes))
+
+	for q := range gaussLegendre8C {
+		t := a + (b-a)*gaussLegendre8C[q]
+		for k := range nodes {
+			l := 1.
+			for m := range nodes {
+				if m != k {
+					l *= (t - nodes[m]) / (nodes[k] - nodes[m])
+				}
+			}
+			weights[k] += (b - a) * gaussLegendre8B[q] * l
+		}
+	}
+	return weights
+}
@@ -0,0 +1,111 @@
+package bvp
+
+import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestSolveDeferredCorrectionMattheij(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(21)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	for i := range MattheijBVP.X {
+		MattheijBVP.X[i] = matrix.Zeros(3, 1)
+	}
+
+	opts := DefaultDeferredCorrectionOptions()
+	opts.Solve.AbsTol = 1e-12
+
+	result, err := (&MattheijBVP).SolveDeferredCorrection(opts)
+
+	if err != nil {
+		t.Errorf("Error solving: %s", err)
+	}
+
+	if len(result.Orders) != 2 || result.Orders[0] != 4 || result.Orders[1] != 6 {
+		t.Errorf("Incorrect correction orders, got %v", result.Orders)
+	}
+
+	if len(result.Errors) != 2 || result.Errors[1] > result.Errors[0] {
+		t.Errorf("Expected decreasing error estimates, got %v", result.Errors)
+	}
+
+	// the trapezoidal error on this mesh is around 3e-5
+	e := mattheijMaxError(&MattheijBVP)
+	if e > 5e-7 {
+		t.Errorf("Error against exact solution too large, got %g", e)
+	}
+
+	// the estimate is relative to 1 + |x| <= 1 + e
+	if len(result.Errors) == 2 && (result.Errors[1] < e/(1+math.E)/10 || result.Errors[1] > 10*e) {
+		t.Errorf("Final error estimate %g is not close to the error %g", result.Errors[1], e)
+	}
+
+	if MattheijBVP.correction != nil {
+		t.Errorf("Correction not cleared after solve")
+	}
+}
+
+func TestInterpolatoryWeights(t *testing.T) {
+	// Simpson's rule
+	weights := interpolatoryWeights([]float64{0, 0.5, 1}, 0, 1)
+	expected := []float64{1. / 6., 2. / 3., 1. / 6.}
+
+	for k := range weights {
+		if d := weights[k] - expected[k]; d > 1e-14 || d < -1e-14 {
+			t.Errorf("Incorrect weight %d, expected %g, got %g", k, expected[k], weights[k])
+		}
+	}
+}
+
+func TestInterpolatoryWeightsEightNodes(t *testing.T) {
+	nodes := []float64{0, 1, 2, 3, 4, 5, 6, 7}
+
+	// integral of t^7 over [3, 4]
+	weights := interpolatoryWeights(nodes, 3, 4)
+	var integral float64
+	for k, w := range weights {
+		integral += w * math.Pow(nodes[k], 7)
+	}
+
+	expected := (math.Pow(4, 8) - math.Pow(3, 8)) / 8
+	if math.Abs(integral-expected) > 1e-9*expected {
+		t.Errorf("Incorrect integral, expected %g, got %g", expected, integral)
+	}
+}
+
+func TestSolveDeferredCorrectionChecksOptions(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(21)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	opts := DefaultDeferredCorrectionOptions()
+	opts.Sweeps = 3
+
+	_, err = (&MattheijBVP).SolveDeferredCorrection(opts)
+
+	var argErr ArgumentError
+	if !errors.As(err, &argErr) || argErr.Argument != "Sweeps" {
+		t.Errorf("Expected an ArgumentError for Sweeps, got %v", err)
+	}
+
+	// 2 sweeps need 8 mesh points for the final error estimate
+	MattheijBVP, err = newMattheijBVP(7)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	_, err = (&MattheijBVP).SolveDeferredCorrection(DefaultDeferredCorrectionOptions())
+
+	if !errors.As(err, &argErr) || argErr.Argument != "bvp" {
+		t.Errorf("Expected an ArgumentError for too few mesh points, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// The split of the modes of the linearised ODE into growing and decaying
+// directions along the current solution. Growing modes must be fixed by
+// conditions at T[N-1] and decaying modes by conditions at T[0] for the BVP
+// to be well conditioned.
+type Dichotomy struct {
+	// Average exponential growth rate of each mode over [T[0], T[N-1]], in
+	// the order of the columns of Directions
+	Exponents []float64
+
+	// Orthonormal columns at T[N-1] from propagating the identity. The
+	// first k columns span the image of the first k unit vectors at T[0],
+	// so they are nested subspaces. Once the modes separate the Exponents
+	// decrease and the first Growing columns span the growing directions,
+	// but this is not guaranteed: a mode which never mixes with the others,
+	// such as an axis of a diagonal system, stays in its original column.
+	Directions *matrix.DenseMatrix
+
+	// Modes changing by less than a factor of e over the interval are
+	// neutral and may be fixed at either end
+	Growing, Decaying, Neutral int
+}
+
+// Returns the dichotomy of the discretized linearisation about X, found by
+// propagating an orthonormal basis through the one step maps -B_i^-1 A_i
+// with repeated QR factorisations, so that the growth of each mode is
+// accumulated without overflow.
+func (bvp *BVP) Dichotomy() (dichotomy Dichotomy, err error) {
+	A, B, err := ConstraintMatrixBlocks(bvp)
+	if err != nil {
+		return
+	}
+
+	m := bvp.ODE.P
+	Q := matrix.Eye(m)
+	logGrowth := make([]float64, m)
+
+	for i := 0; i < bvp.N-1; i++ {
+		step, err := solveColumns(B[i], matrix.Scaled(A[i], -1))
+		if err != nil {
+			return dichotomy, err
+		}
+
+		var R *matrix.DenseMatrix
+		Q, R = matrix.Product(step, Q).QR()
+
+		for j := 0; j < m; j++ {
+			r := math.Abs(R.Get(j, j))
+			if r == 0 || math.IsNaN(r) {
+				return dichotomy, SingularSystemError{i + 1}
+			}
+			logGrowth[j] += math.Log(r)
+		}
+	}
+
+	span := bvp.T[bvp.N-1] - bvp.T[0]
+
+	// the columns are not permuted, which would break the nesting
+	dichotomy.Exponents = make([]float64, m)
+	dichotomy.Directions = Q
+	for j := 0; j < m; j++ {
+		dichotomy.Exponents[j] = logGrowth[j] / span
+
+		switch {
+		case logGrowth[j] > 1:
+			dichotomy.Growing++
+		case logGrowth[j] < -1:
+			dichotomy.Decaying++
+		default:
+			dichotomy.Neutral++
+		}
+	}
+	return
+}
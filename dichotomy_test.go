@@ -0,0 +1,181 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+// x' = diag(2, -3) x on [0, 1]
+var splitODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{2 * x.Get(0, 0), -3 * x.Get(1, 0)}, 2, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{2, 0, 0, -3}, 2, 2)
+	},
+	2, 0,
+)
+
+func TestDichotomy(t *testing.T) {
+	n := 201
+	timeMesh := make([]float64, n)
+	initialGuess := make([]matrix.Matrix, n)
+	for i := range timeMesh {
+		timeMesh[i] = float64(i) / float64(n-1)
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{math.Exp(2 * timeMesh[i]), math.Exp(-3 * timeMesh[i])}, 2, 1)
+	}
+
+	// both conditions at t = 0, which is poor for the growing mode
+	B0 := matrix.Eye(2)
+	B1 := matrix.Zeros(2, 2)
+	b := matrix.Ones(2, 1)
+
+	bvp, err := NewBVPWithInitialGuess(splitODE, initialGuess, timeMesh, B0, B1, matrix.Zeros(0, 1), b)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	d, err := bvp.Dichotomy()
+	if err != nil {
+		t.Errorf("Error finding dichotomy: %s", err)
+		return
+	}
+
+	if d.Growing != 1 || d.Decaying != 1 || d.Neutral != 0 {
+		t.Errorf("Expected one growing and one decaying mode, got %d, %d and %d neutral", d.Growing, d.Decaying, d.Neutral)
+	}
+
+	if math.Abs(d.Exponents[0]-2) > 1e-3 || math.Abs(d.Exponents[1]+3) > 1e-3 {
+		t.Errorf("Exponents %v, expected [2 -3]", d.Exponents)
+	}
+
+	// the growing direction is the first component
+	if math.Abs(math.Abs(d.Directions.Get(0, 0))-1) > 1e-8 {
+		t.Errorf("Growing direction %v, expected the first axis", d.Directions.GetColVector(0))
+	}
+}
+
+func TestDichotomyMattheij(t *testing.T) {
+	bvp, err := newMattheijBVP(1001)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP: %s", err)
+		return
+	}
+
+	d, err := bvp.Dichotomy()
+	if err != nil {
+		t.Errorf("Error finding dichotomy: %s", err)
+		return
+	}
+
+	if d.Growing != 2 || d.Decaying != 1 {
+		t.Errorf("Expected two growing modes and one decaying, got %d and %d", d.Growing, d.Decaying)
+	}
+}
+
+func TestSetOptimalBoundaryMatricesTwoStates(t *testing.T) {
+	n := 201
+	timeMesh := make([]float64, n)
+	initialGuess := make([]matrix.Matrix, n)
+	for i := range timeMesh {
+		timeMesh[i] = float64(i) / float64(n-1)
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{math.Exp(2 * timeMesh[i]), math.Exp(-3 * timeMesh[i])}, 2, 1)
+	}
+
+	// both conditions at t = 0, which is poor for the growing mode
+	B0 := matrix.Eye(2)
+	B1 := matrix.Zeros(2, 2)
+	b := matrix.Ones(2, 1)
+
+	bvp, err := NewBVPWithInitialGuess(splitODE, initialGuess, timeMesh, B0, B1, matrix.Zeros(0, 1), b)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	before, err := bvp.Conditioning()
+	if err != nil {
+		t.Errorf("Error estimating conditioning: %s", err)
+		return
+	}
+
+	d, err := SetOptimalBoundaryMatrices(&bvp)
+	if err != nil {
+		t.Errorf("Error setting boundary matrices: %s", err)
+		return
+	}
+
+	if d.Growing != 1 || d.Decaying != 1 {
+		t.Errorf("Expected one growing and one decaying mode")
+	}
+
+	if bvp.B0.Rows() != 2 || bvp.B0.Cols() != 2 || bvp.B1.Rows() != 2 || bvp.B1.Cols() != 2 {
+		t.Errorf("Expected 2 by 2 boundary matrices")
+		return
+	}
+
+	after, err := bvp.Conditioning()
+	if err != nil {
+		t.Errorf("Error estimating conditioning: %s", err)
+		return
+	}
+
+	if after.StabilityConstant >= before.StabilityConstant {
+		t.Errorf("Expected better conditioning, got %f before and %f after", before.StabilityConstant, after.StabilityConstant)
+	}
+
+	if err := bvp.Solve(); err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+	}
+}
+
+func TestDichotomyUnordered(t *testing.T) {
+	// x' = diag(-3, 2) x, whose axes never mix so the decaying mode stays first
+	ode := NewODE(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.MakeDenseMatrix([]float64{-3 * x.Get(0, 0), 2 * x.Get(1, 0)}, 2, 1)
+		},
+		nil, 2, 0,
+	)
+
+	n := 201
+	timeMesh := make([]float64, n)
+	initialGuess := make([]matrix.Matrix, n)
+	for i := range timeMesh {
+		timeMesh[i] = float64(i) / float64(n-1)
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{math.Exp(2 * timeMesh[i]), math.Exp(-3 * timeMesh[i])}, 2, 1)
+	}
+
+	// both conditions at t = 0, which is poor for the growing mode
+	B0 := matrix.Eye(2)
+	B1 := matrix.Zeros(2, 2)
+	b := matrix.Ones(2, 1)
+
+	bvp, err := NewBVPWithInitialGuess(splitODE, initialGuess, timeMesh, B0, B1, matrix.Zeros(0, 1), b)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+	bvp.ODE = ode
+
+	d, err := bvp.Dichotomy()
+	if err != nil {
+		t.Errorf("Error finding dichotomy: %s", err)
+		return
+	}
+
+	if d.Growing != 1 || d.Decaying != 1 {
+		t.Errorf("Expected one growing and one decaying mode, got %d and %d", d.Growing, d.Decaying)
+	}
+
+	// the exponents stay with their columns rather than being sorted
+	if math.Abs(d.Exponents[0]+3) > 1e-3 || math.Abs(d.Exponents[1]-2) > 1e-3 {
+		t.Errorf("Exponents %v, expected [-3 2]", d.Exponents)
+	}
+
+	if math.Abs(math.Abs(d.Directions.Get(1, 1))-1) > 1e-8 {
+		t.Errorf("Growing direction %v, expected the second axis", d.Directions.GetColVector(1))
+	}
+}
@@ -2,19 +2,33 @@ package bvp
 
 import (
 	"fmt"
+	"github.com/sbroadfoot90/go.matrix"
 )
 
 type DimensionError struct {
 	VariableName                                       string
 	ExpectedRow, ExpectedCol, ReceivedRow, ReceivedCol int
+
+	// name of the function which received the variable, empty if unknown
+	Function string
 }
 
 func (de DimensionError) Error() string {
-	return fmt.Sprintf("Variable %s, received dimensions (%d, %d), expected dimensions (%d, %d)", de.VariableName, de.ReceivedRow, de.ReceivedCol, de.ExpectedRow, de.ExpectedCol)
+	message := fmt.Sprintf("Variable %s, received dimensions (%d, %d), expected dimensions (%d, %d)", de.VariableName, de.ReceivedRow, de.ReceivedCol, de.ExpectedRow, de.ExpectedCol)
+	if de.Function != "" {
+		return de.Function + ": " + message
+	}
+	return message
 }
 
 func NewDimensionError(variableName string, er, ec, rr, rc int) DimensionError {
-	return DimensionError{variableName, er, ec, rr, rc}
+	return DimensionError{variableName, er, ec, rr, rc, ""}
+}
+
+// As NewDimensionError, annotated with the function which received the
+// variable
+func NewDimensionErrorIn(function, variableName string, er, ec, rr, rc int) DimensionError {
+	return DimensionError{variableName, er, ec, rr, rc, function}
 }
 
 type MatrixError string
@@ -23,8 +37,80 @@ func (me MatrixError) Error() string {
 	return string(me)
 }
 
+// An argument or option outside the range a function supports
+type ArgumentError struct {
+	Function string // function which received the argument
+	Argument string
+	Message  string
+}
+
+func (e ArgumentError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Function, e.Argument, e.Message)
+}
+
 type ConvergeError string
 
 func (ce ConvergeError) Error() string {
 	return string(ce)
 }
+
+// An iterative solver which stopped without converging. It unwraps to a
+// ConvergeError holding Message.
+type NonConvergenceError struct {
+	Message    string
+	Iterations int     // iterations taken, or mesh refinements for SolveAdaptive
+	Cost       float64 // cost at the last iterate, as reported by the solver
+	StepNorm   float64 // 2-norm of the last step
+}
+
+func (e NonConvergenceError) Error() string {
+	return fmt.Sprintf("%s after %d iterations, cost %g, step norm %g", e.Message, e.Iterations, e.Cost, e.StepNorm)
+}
+
+func (e NonConvergenceError) Unwrap() error {
+	return ConvergeError(e.Message)
+}
+
+// A factorisation which broke down. Index is the mesh index of the unknown
+// whose pivot vanished, or N-1 for the end system coupling x(T[0]) and
+// x(T[N-1]) and for the bordered continuation system. It unwraps to
+// matrix.ExceptionSingular.
+type SingularSystemError struct {
+	Index int
+}
+
+func (e SingularSystemError) Error() string {
+	return fmt.Sprintf("Linear system is singular at mesh index %d", e.Index)
+}
+
+func (e SingularSystemError) Unwrap() error {
+	return matrix.ExceptionSingular
+}
+
+// A failure evaluating F, Dfdx or Dfdbeta. For the residual or Jacobian of a
+// mesh interval Index and T are those of its left end, and for an IVP they
+// are the last accepted step.
+type EvaluationError struct {
+	Function string // "F", "Dfdx" or "Dfdbeta"
+	Index    int
+	T        float64
+	Err      error
+}
+
+func (e EvaluationError) Error() string {
+	return fmt.Sprintf("Evaluating %s at mesh index %d, t = %g: %s", e.Function, e.Index, e.T, e.Err)
+}
+
+func (e EvaluationError) Unwrap() error {
+	return e.Err
+}
+
+// Wraps a non nil err in an EvaluationError, unless it already is one or is
+// a failure of the solver rather than of the ODE
+func evaluationError(function string, index int, t float64, err error) error {
+	switch err.(type) {
+	case nil, EvaluationError, NonConvergenceError, SingularSystemError:
+		return err
+	}
+	return EvaluationError{function, index, t, err}
+}
@@ -1,6 +1,9 @@
 package bvp
 
 import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
 	"testing"
 )
 
@@ -12,3 +15,107 @@ func TestDimensionError(t *testing.T) {
 		t.Errorf("DimensionError not returning expected string representation")
 	}
 }
+
+func TestDimensionErrorFunction(t *testing.T) {
+	testError := NewDimensionErrorIn("ODE.F", "x", 4, 6, 5, 7)
+
+	if testError.Error() != "ODE.F: Variable x, received dimensions (5, 7), expected dimensions (4, 6)" {
+		t.Errorf("DimensionError not returning expected string representation")
+	}
+}
+
+// x'' = -x whose f returns the wrong dimensions for t > 0.5
+var brokenODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		if t > 0.5 {
+			return matrix.Zeros(3, 1)
+		}
+		return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -x.Get(0, 0)}, 2, 1)
+	},
+	nil, 2, 1,
+)
+
+func TestEvaluationError(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(11, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+	bvp.ODE = brokenODE
+
+	err = bvp.Solve()
+
+	var evalErr EvaluationError
+	if !errors.As(err, &evalErr) {
+		t.Errorf("Expected an EvaluationError, got %v", err)
+		return
+	}
+
+	// the first interval to reach t > 0.5 is [0.5, 0.6]
+	if evalErr.Function != "F" || evalErr.Index != 5 || math.Abs(evalErr.T-0.5) > 1e-12 {
+		t.Errorf("Unexpected context %s at mesh index %d, t = %f", evalErr.Function, evalErr.Index, evalErr.T)
+	}
+
+	var dimErr DimensionError
+	if !errors.As(err, &dimErr) || dimErr.Function != "ODE.F" || dimErr.VariableName != "f" {
+		t.Errorf("Expected a DimensionError from ODE.F, got %v", err)
+	}
+}
+
+func TestNonConvergenceError(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(11, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+	for i := range bvp.X {
+		bvp.X[i] = matrix.Zeros(2, 1)
+	}
+
+	opts := DefaultSolveOptions()
+	opts.MaxIterations = 1
+	result, err := bvp.SolveWithOptions(opts)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) {
+		t.Errorf("Expected a NonConvergenceError, got %v", err)
+		return
+	}
+
+	if convErr.Iterations != 1 || convErr.Cost != result.Cost || convErr.StepNorm != result.StepNorm {
+		t.Errorf("NonConvergenceError %v does not match the result", convErr)
+	}
+
+	var ce ConvergeError
+	if !errors.As(err, &ce) || string(ce) != "Warning: BVP solver did not terminate" {
+		t.Errorf("Expected the NonConvergenceError to unwrap to a ConvergeError")
+	}
+}
+
+func TestSingularSystemError(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(11, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	// no boundary conditions, so the end system is singular
+	bvp.B0 = matrix.Zeros(2, 2)
+	bvp.B1 = matrix.Zeros(2, 2)
+
+	err = bvp.Solve()
+
+	var singErr SingularSystemError
+	if !errors.As(err, &singErr) {
+		t.Errorf("Expected a SingularSystemError, got %v", err)
+		return
+	}
+
+	if !errors.Is(err, matrix.ExceptionSingular) {
+		t.Errorf("Expected the SingularSystemError to unwrap to matrix.ExceptionSingular")
+	}
+
+	if singErr.Index != bvp.N-1 {
+		t.Errorf("Expected the end system at mesh index %d, got %d", bvp.N-1, singErr.Index)
+	}
+}
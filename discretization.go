@@ -0,0 +1,406 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// A Discretization replaces the ODE on the mesh interval [ti, tj] by a
+// residual r(xi, xj) which vanishes at the discrete solution. Jacobian returns
+// the blocks A = dr/dxi and B = dr/dxj consumed by rightOrthogonalFactorisation
+// and ParameterJacobian returns the P by Q block dr/dbeta.
+type Discretization interface {
+	Residual(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error)
+	Jacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (A, B *matrix.DenseMatrix, err error)
+	ParameterJacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error)
+	Order() int
+}
+
+// The trapezoidal rule, r = xj - xi - h/2 (f(xi) + f(xj))
+type Trapezoidal struct{}
+
+func (Trapezoidal) Order() int { return 2 }
+
+func (Trapezoidal) Residual(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error) {
+	fi, err := ode.F(xi, ti, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	fj, err := ode.F(xj, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	return matrix.Difference(
+		matrix.Difference(xj, xi),
+		matrix.Scaled(matrix.Sum(fi, fj), (tj-ti)/2),
+	), nil
+}
+
+func (Trapezoidal) Jacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (A, B *matrix.DenseMatrix, err error) {
+	dfdxi, err := ode.Dfdx(xi, ti, beta)
+	if err != nil {
+		return
+	}
+
+	dfdxj, err := ode.Dfdx(xj, tj, beta)
+	if err != nil {
+		return
+	}
+
+	A = matrix.Difference(
+		matrix.Scaled(matrix.Eye(ode.P), -1),
+		matrix.Scaled(dfdxi, (tj-ti)/2),
+	)
+
+	B = matrix.Difference(
+		matrix.Eye(ode.P),
+		matrix.Scaled(dfdxj, (tj-ti)/2),
+	)
+	return
+}
+
+func (Trapezoidal) ParameterJacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error) {
+	dfdbetai, err := ode.Dfdbeta(xi, ti, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	dfdbetaj, err := ode.Dfdbeta(xj, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	return matrix.Scaled(matrix.Sum(dfdbetai, dfdbetaj), -(tj-ti)/2), nil
+}
+
+// Hermite-Simpson collocation with the midpoint value condensed out,
+//
+//	xm = (xi + xj)/2 + h/8 (f(xi) - f(xj))
+//	r  = xj - xi - h/6 (f(xi) + 4 f(xm) + f(xj))
+type HermiteSimpson struct{}
+
+func (HermiteSimpson) Order() int { return 4 }
+
+func (HermiteSimpson) midpoint(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (fi, fj, xm *matrix.DenseMatrix, err error) {
+	f, err := ode.F(xi, ti, beta)
+	if err != nil {
+		return
+	}
+	fi = matrix.MakeDenseCopy(f)
+
+	f, err = ode.F(xj, tj, beta)
+	if err != nil {
+		return
+	}
+	fj = matrix.MakeDenseCopy(f)
+
+	xm = matrix.Sum(
+		matrix.Scaled(matrix.Sum(xi, xj), 0.5),
+		matrix.Scaled(matrix.Difference(fi, fj), (tj-ti)/8),
+	)
+	return
+}
+
+func (hs HermiteSimpson) Residual(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error) {
+	fi, fj, xm, err := hs.midpoint(ode, xi, xj, ti, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, err := ode.F(xm, (ti+tj)/2, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	return matrix.Difference(
+		matrix.Difference(xj, xi),
+		matrix.Scaled(matrix.Sum(fi, matrix.Scaled(fm, 4), fj), (tj-ti)/6),
+	), nil
+}
+
+func (hs HermiteSimpson) Jacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (A, B *matrix.DenseMatrix, err error) {
+	h := tj - ti
+
+	_, _, xm, err := hs.midpoint(ode, xi, xj, ti, tj, beta)
+	if err != nil {
+		return
+	}
+
+	dfdxi, err := ode.Dfdx(xi, ti, beta)
+	if err != nil {
+		return
+	}
+
+	dfdxj, err := ode.Dfdx(xj, tj, beta)
+	if err != nil {
+		return
+	}
+
+	dfdxm, err := ode.Dfdx(xm, (ti+tj)/2, beta)
+	if err != nil {
+		return
+	}
+
+	// derivatives of the condensed midpoint value
+	dxmdxi := matrix.Sum(matrix.Scaled(matrix.Eye(ode.P), 0.5), matrix.Scaled(dfdxi, h/8))
+	dxmdxj := matrix.Difference(matrix.Scaled(matrix.Eye(ode.P), 0.5), matrix.Scaled(dfdxj, h/8))
+
+	A = matrix.Difference(
+		matrix.Scaled(matrix.Eye(ode.P), -1),
+		matrix.Scaled(matrix.Sum(dfdxi, matrix.Scaled(matrix.Product(dfdxm, dxmdxi), 4)), h/6),
+	)
+
+	B = matrix.Difference(
+		matrix.Eye(ode.P),
+		matrix.Scaled(matrix.Sum(dfdxj, matrix.Scaled(matrix.Product(dfdxm, dxmdxj), 4)), h/6),
+	)
+	return
+}
+
+func (hs HermiteSimpson) ParameterJacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error) {
+	h := tj - ti
+
+	_, _, xm, err := hs.midpoint(ode, xi, xj, ti, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	dfdbetai, err := ode.Dfdbeta(xi, ti, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	dfdbetaj, err := ode.Dfdbeta(xj, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	dfdbetam, err := ode.Dfdbeta(xm, (ti+tj)/2, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	dfdxm, err := ode.Dfdx(xm, (ti+tj)/2, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	// derivative of the condensed midpoint value
+	dxmdbeta := matrix.Scaled(matrix.Difference(dfdbetai, dfdbetaj), h/8)
+
+	return matrix.Scaled(
+		matrix.Sum(dfdbetai, matrix.Scaled(matrix.Sum(dfdbetam, matrix.Product(dfdxm, dxmdbeta)), 4), dfdbetaj),
+		-h/6,
+	), nil
+}
+
+// Collocation at the stages of an implicit Runge-Kutta method. The stage
+// derivatives K are condensed out of each interval by an inner Newton
+// iteration, so that r = xj - xi - h sum_s B[s] K[s] depends only on xi and xj.
+type Collocation struct {
+	C, B  []float64
+	A     [][]float64
+	order int
+}
+
+var (
+	GaussLegendre4 = &Collocation{
+		C: []float64{0.5 - math.Sqrt(3)/6, 0.5 + math.Sqrt(3)/6},
+		B: []float64{0.5, 0.5},
+		A: [][]float64{
+			{0.25, 0.25 - math.Sqrt(3)/6},
+			{0.25 + math.Sqrt(3)/6, 0.25},
+		},
+		order: 4,
+	}
+
+	GaussLegendre6 = &Collocation{
+		C: []float64{0.5 - math.Sqrt(15)/10, 0.5, 0.5 + math.Sqrt(15)/10},
+		B: []float64{5. / 18., 4. / 9., 5. / 18.},
+		A: [][]float64{
+			{5. / 36., 2./9. - math.Sqrt(15)/15, 5./36. - math.Sqrt(15)/30},
+			{5./36. + math.Sqrt(15)/24, 2. / 9., 5./36. - math.Sqrt(15)/24},
+			{5./36. + math.Sqrt(15)/30, 2./9. + math.Sqrt(15)/15, 5. / 36.},
+		},
+		order: 6,
+	}
+
+	LobattoIIIA4 = &Collocation{
+		C: []float64{0, 0.5, 1},
+		B: []float64{1. / 6., 2. / 3., 1. / 6.},
+		A: [][]float64{
+			{0, 0, 0},
+			{5. / 24., 1. / 3., -1. / 24.},
+			{1. / 6., 2. / 3., 1. / 6.},
+		},
+		order: 4,
+	}
+
+	LobattoIIIA6 = &Collocation{
+		C: []float64{0, (5 - math.Sqrt(5)) / 10, (5 + math.Sqrt(5)) / 10, 1},
+		B: []float64{1. / 12., 5. / 12., 5. / 12., 1. / 12.},
+		A: [][]float64{
+			{0, 0, 0, 0},
+			{(11 + math.Sqrt(5)) / 120, (25 - math.Sqrt(5)) / 120, (25 - 13*math.Sqrt(5)) / 120, (-1 + math.Sqrt(5)) / 120},
+			{(11 - math.Sqrt(5)) / 120, (25 + 13*math.Sqrt(5)) / 120, (25 + math.Sqrt(5)) / 120, (-1 - math.Sqrt(5)) / 120},
+			{1. / 12., 5. / 12., 5. / 12., 1. / 12.},
+		},
+		order: 6,
+	}
+)
+
+func (c *Collocation) Order() int { return c.order }
+
+// Solves the stage equations K[s] = f(xi + h sum_l A[s][l] K[l], ti + C[s] h)
+// by Newton iteration, starting from the secant slope between xi and xj.
+// Returns the stage derivatives, the stage Jacobians dfdx and the Newton
+// matrix M = I - h (A (x) dfdx) at the converged stages.
+func (c *Collocation) stages(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (K, dfdx []*matrix.DenseMatrix, M *matrix.DenseMatrix, err error) {
+	tolerance := 1e-12
+	maxiter := 50
+
+	s := len(c.B)
+	p := ode.P
+	h := tj - ti
+
+	K = make([]*matrix.DenseMatrix, s)
+	dfdx = make([]*matrix.DenseMatrix, s)
+	for l := 0; l < s; l++ {
+		K[l] = matrix.Scaled(matrix.Difference(xj, xi), 1/h)
+	}
+
+	var cost, stepNorm float64
+	for iter := 0; iter < maxiter; iter++ {
+		G := matrix.Zeros(s*p, 1)
+		M = matrix.Eye(s * p)
+
+		for r := 0; r < s; r++ {
+			y := matrix.MakeDenseCopy(xi)
+			for l := 0; l < s; l++ {
+				y.Add(matrix.Scaled(K[l], h*c.A[r][l]))
+			}
+
+			f, err := ode.F(y, ti+c.C[r]*h, beta)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			J, err := ode.Dfdx(y, ti+c.C[r]*h, beta)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			dfdx[r] = matrix.MakeDenseCopy(J)
+
+			G.SetMatrix(r*p, 0, matrix.Difference(K[r], f))
+			for l := 0; l < s; l++ {
+				block := matrix.Scaled(dfdx[r], -h*c.A[r][l])
+				if l == r {
+					block.Add(matrix.Eye(p))
+				}
+				M.SetMatrix(r*p, l*p, block)
+			}
+		}
+
+		dK, err := M.Solve(G)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		cost, stepNorm = math.Pow(G.TwoNorm(), 2), dK.TwoNorm()
+
+		var stepMax, kMax float64
+		for r := 0; r < s; r++ {
+			K[r].Subtract(dK.GetMatrix(r*p, 0, p, 1))
+			for j := 0; j < p; j++ {
+				stepMax = math.Max(stepMax, math.Abs(dK.Get(r*p+j, 0)))
+				kMax = math.Max(kMax, math.Abs(K[r].Get(j, 0)))
+			}
+		}
+
+		if stepMax <= tolerance*(1+kMax) {
+			return K, dfdx, M, nil
+		}
+	}
+
+	return nil, nil, nil, NonConvergenceError{"Collocation stage equations did not converge", maxiter, cost, stepNorm}
+}
+
+func (c *Collocation) Residual(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error) {
+	K, _, _, err := c.stages(ode, xi, xj, ti, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	r := matrix.Difference(xj, xi)
+	for l := range K {
+		r.Subtract(matrix.Scaled(K[l], (tj-ti)*c.B[l]))
+	}
+	return r, nil
+}
+
+func (c *Collocation) Jacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (A, B *matrix.DenseMatrix, err error) {
+	_, dfdx, M, err := c.stages(ode, xi, xj, ti, tj, beta)
+	if err != nil {
+		return
+	}
+
+	s := len(c.B)
+	p := ode.P
+
+	// dK/dxi = M^-1 [dfdx[0]; ... ; dfdx[s-1]]
+	rhs := matrix.Zeros(s*p, p)
+	for r := 0; r < s; r++ {
+		rhs.SetMatrix(r*p, 0, dfdx[r])
+	}
+
+	dKdxi, err := solveColumns(M, rhs)
+	if err != nil {
+		return
+	}
+
+	A = matrix.Scaled(matrix.Eye(p), -1)
+	for l := 0; l < s; l++ {
+		A.Subtract(matrix.Scaled(dKdxi.GetMatrix(l*p, 0, p, p), (tj-ti)*c.B[l]))
+	}
+
+	B = matrix.Eye(p)
+	return
+}
+
+func (c *Collocation) ParameterJacobian(ode *ODE, xi, xj matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (*matrix.DenseMatrix, error) {
+	K, _, M, err := c.stages(ode, xi, xj, ti, tj, beta)
+	if err != nil {
+		return nil, err
+	}
+
+	s := len(c.B)
+	p := ode.P
+	h := tj - ti
+
+	// dK/dbeta = M^-1 [dfdbeta[0]; ... ; dfdbeta[s-1]]
+	rhs := matrix.Zeros(s*p, ode.Q)
+	for r := 0; r < s; r++ {
+		y := matrix.MakeDenseCopy(xi)
+		for l := 0; l < s; l++ {
+			y.Add(matrix.Scaled(K[l], h*c.A[r][l]))
+		}
+
+		dfdbeta, err := ode.Dfdbeta(y, ti+c.C[r]*h, beta)
+		if err != nil {
+			return nil, err
+		}
+		rhs.SetMatrix(r*p, 0, matrix.MakeDenseCopy(dfdbeta))
+	}
+
+	dKdbeta, err := solveColumns(M, rhs)
+	if err != nil {
+		return nil, err
+	}
+
+	drdbeta := matrix.Zeros(p, ode.Q)
+	for l := 0; l < s; l++ {
+		drdbeta.Subtract(matrix.Scaled(dKdbeta.GetMatrix(l*p, 0, p, ode.Q), h*c.B[l]))
+	}
+	return drdbeta, nil
+}
@@ -0,0 +1,135 @@
+package bvp
+
+import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestDiscretizationAccuracy(t *testing.T) {
+	discretizations := []struct {
+		name      string
+		disc      Discretization
+		tolerance float64
+	}{
+		{"Trapezoidal", Trapezoidal{}, 1e-4},
+		{"HermiteSimpson", HermiteSimpson{}, 1e-7},
+		{"GaussLegendre4", GaussLegendre4, 5e-6},
+		{"GaussLegendre6", GaussLegendre6, 1e-9},
+		{"LobattoIIIA4", LobattoIIIA4, 1e-7},
+		{"LobattoIIIA6", LobattoIIIA6, 1e-9},
+	}
+
+	for _, d := range discretizations {
+		MattheijBVP, err := newMattheijBVP(21)
+
+		if err != nil {
+			t.Errorf("Error creating Mattheij BVP")
+		}
+
+		MattheijBVP.Discretization = d.disc
+		for i := range MattheijBVP.X {
+			MattheijBVP.X[i] = matrix.Zeros(3, 1)
+		}
+
+		opts := DefaultSolveOptions()
+		opts.AbsTol = 1e-10
+
+		_, err = (&MattheijBVP).SolveWithOptions(opts)
+
+		if err != nil {
+			t.Errorf("%s: error solving: %s", d.name, err)
+			continue
+		}
+
+		if e := mattheijMaxError(&MattheijBVP); e > d.tolerance {
+			t.Errorf("%s: error too large, expected below %g, got %g", d.name, d.tolerance, e)
+		}
+	}
+}
+
+var pendulumODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -beta.Get(0, 0) * math.Sin(x.Get(0, 0))}, 2, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, 1, -beta.Get(0, 0) * math.Cos(x.Get(0, 0)), 0}, 2, 2)
+	},
+	2, 1,
+)
+
+func TestDiscretizationJacobian(t *testing.T) {
+	discretizations := []Discretization{Trapezoidal{}, HermiteSimpson{}, GaussLegendre4, GaussLegendre6, LobattoIIIA4, LobattoIIIA6}
+
+	xi := matrix.MakeDenseMatrix([]float64{1, 0.5}, 2, 1)
+	xj := matrix.MakeDenseMatrix([]float64{1.05, 0.4}, 2, 1)
+	beta := matrix.MakeDenseMatrix([]float64{9.81}, 1, 1)
+	ti, tj := 0.0, 0.1
+	eps := 1e-6
+
+	for _, disc := range discretizations {
+		A, B, err := disc.Jacobian(&pendulumODE, xi, xj, ti, tj, beta)
+
+		if err != nil {
+			t.Errorf("Error calculating Jacobian: %s", err)
+			continue
+		}
+
+		for k := 0; k < 2; k++ {
+			xiPlus, xiMinus := xi.Copy(), xi.Copy()
+			xiPlus.Set(k, 0, xi.Get(k, 0)+eps)
+			xiMinus.Set(k, 0, xi.Get(k, 0)-eps)
+			rPlus, _ := disc.Residual(&pendulumODE, xiPlus, xj, ti, tj, beta)
+			rMinus, _ := disc.Residual(&pendulumODE, xiMinus, xj, ti, tj, beta)
+
+			xjPlus, xjMinus := xj.Copy(), xj.Copy()
+			xjPlus.Set(k, 0, xj.Get(k, 0)+eps)
+			xjMinus.Set(k, 0, xj.Get(k, 0)-eps)
+			sPlus, _ := disc.Residual(&pendulumODE, xi, xjPlus, ti, tj, beta)
+			sMinus, _ := disc.Residual(&pendulumODE, xi, xjMinus, ti, tj, beta)
+
+			for j := 0; j < 2; j++ {
+				if math.Abs((rPlus.Get(j, 0)-rMinus.Get(j, 0))/(2*eps)-A.Get(j, k)) > 1e-6 {
+					t.Errorf("Order %d discretization: incorrect A(%d, %d)", disc.Order(), j, k)
+				}
+				if math.Abs((sPlus.Get(j, 0)-sMinus.Get(j, 0))/(2*eps)-B.Get(j, k)) > 1e-6 {
+					t.Errorf("Order %d discretization: incorrect B(%d, %d)", disc.Order(), j, k)
+				}
+			}
+		}
+	}
+}
+
+func TestCollocationNonConvergence(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(3, 2)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	// x' = 100 x with a zero dfdx, so the stage iteration diverges on a
+	// mesh interval of length 1
+	bvp.ODE = NewODE(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.Scaled(x, 100)
+		},
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.Zeros(2, 2)
+		},
+		2, 1,
+	)
+	bvp.Discretization = GaussLegendre4
+
+	_, err = ConstraintVectorBlocks(&bvp)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) {
+		t.Errorf("Expected a NonConvergenceError, got %v", err)
+	}
+
+	var evalErr EvaluationError
+	if errors.As(err, &evalErr) {
+		t.Errorf("Stage non-convergence should not be reported as an evaluation error")
+	}
+}
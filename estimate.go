@@ -0,0 +1,288 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// Options for Estimate
+type EstimateOptions struct {
+	MaxIterations int     // maximum number of Gauss-Newton iterations
+	Tolerance     float64 // stop when every |dtheta| <= Tolerance*(1 + |theta|)
+	MaxLineSearch int     // maximum number of step halvings per iteration
+
+	// Relative step used for finite difference derivatives of X with respect
+	// to Beta and b when Sensitivities is unavailable
+	DifferenceStep float64
+
+	Solve SolveOptions // options for each BVP solve
+}
+
+func DefaultEstimateOptions() EstimateOptions {
+	solve := DefaultSolveOptions()
+	solve.AbsTol = 1e-10
+
+	return EstimateOptions{
+		MaxIterations:  50,
+		Tolerance:      1e-8,
+		MaxLineSearch:  20,
+		DifferenceStep: 1e-6,
+		Solve:          solve,
+	}
+}
+
+// The estimates at one Gauss-Newton iteration
+type EstimateIteration struct {
+	Beta, B *matrix.DenseMatrix
+	Cost    float64
+}
+
+// Report of a call to Estimate
+type EstimateResult struct {
+	Beta, B    *matrix.DenseMatrix // final estimates, also stored in the BVP
+	Cost       float64             // 1/2 sum of squared residuals y_i - O x(t_i)
+	Iterations int
+	History    []EstimateIteration
+	Reason     TerminationReason // Converged, LineSearchFailed or MaxIterations
+
+	// Gauss-Newton approximation s^2 (J^T J)^-1 to the covariance of the
+	// estimates, ordered as Beta followed by b
+	Covariance *matrix.DenseMatrix
+}
+
+// Estimates Beta and the boundary vector b of a BVP with linear boundary
+// conditions from observations y[i] = O x(T[i]) + noise by Gauss-Newton,
+// solving the BVP at each step. This is the embedding method: B0 and B1 are
+// held fixed (see SetOptimalBoundaryMatrices) and b is fitted alongside Beta.
+// Entries of y may be nil where no observation was made.
+func Estimate(bvp *BVP, O matrix.MatrixRO, y []matrix.Matrix, opts EstimateOptions) (result EstimateResult, err error) {
+	if bvp.BC != nil || bvp.Multipoint != nil {
+		return result, MatrixError("Estimate requires linear boundary conditions given by B0, B1 and B")
+	}
+
+	if len(y) != bvp.N {
+		return result, NewDimensionErrorIn("Estimate", "y", bvp.N, 1, len(y), 1)
+	}
+
+	observations := 0
+	for i := range y {
+		if y[i] == nil {
+			continue
+		}
+		if y[i].Rows() != O.Rows() || y[i].Cols() != 1 {
+			return result, NewDimensionErrorIn("Estimate", "y", O.Rows(), 1, y[i].Rows(), y[i].Cols())
+		}
+		observations += O.Rows()
+	}
+
+	if O.Cols() != bvp.ODE.P {
+		return result, NewDimensionErrorIn("Estimate", "O", O.Rows(), bvp.ODE.P, O.Rows(), O.Cols())
+	}
+
+	k := bvp.ODE.Q + bvp.ODE.P
+
+	_, err = bvp.SolveWithOptions(opts.Solve)
+	if err != nil {
+		return
+	}
+
+	r := observationResiduals(bvp, O, y)
+	cost := sumOfSquares(r) / 2
+
+	var J, step *matrix.DenseMatrix
+	var stepNorm float64
+	for result.Iterations = 0; result.Iterations < opts.MaxIterations; result.Iterations++ {
+		result.History = append(result.History, EstimateIteration{
+			matrix.MakeDenseCopy(bvp.Beta), matrix.MakeDenseCopy(bvp.B), cost,
+		})
+
+		J, err = observationJacobian(bvp, O, y, observations, opts)
+		if err != nil {
+			return
+		}
+
+		// Gauss-Newton step solves (J^T J) dtheta = -J^T r
+		JT := J.Transpose()
+		step, err = matrix.Product(JT, J).Solve(matrix.Scaled(matrix.Product(JT, stackBlocks(r)), -1))
+		if err != nil {
+			return
+		}
+		stepNorm = step.TwoNorm()
+
+		theta := parameterVector(bvp)
+		converged := true
+		for j := 0; j < k; j++ {
+			if math.Abs(step.Get(j, 0)) > opts.Tolerance*(1+math.Abs(theta.Get(j, 0))) {
+				converged = false
+			}
+		}
+		if converged {
+			result.Reason = Converged
+			break
+		}
+
+		xold := make([]matrix.Matrix, bvp.N)
+		for i := range bvp.X {
+			xold[i] = matrix.MakeDenseCopy(bvp.X[i])
+		}
+
+		accepted := false
+		for ls := 0; ls < opts.MaxLineSearch; ls++ {
+			setParameterVector(bvp, matrix.Sum(theta, step))
+
+			_, err = bvp.SolveWithOptions(opts.Solve)
+			if err == nil {
+				r = observationResiduals(bvp, O, y)
+				if newCost := sumOfSquares(r) / 2; newCost < cost {
+					cost = newCost
+					accepted = true
+					break
+				}
+			}
+
+			// revert and halve the step
+			for i := range bvp.X {
+				bvp.X[i] = matrix.MakeDenseCopy(xold[i])
+			}
+			step.Scale(0.5)
+		}
+
+		if !accepted {
+			setParameterVector(bvp, theta)
+			r = observationResiduals(bvp, O, y)
+			result.Reason = LineSearchFailed
+			err = NonConvergenceError{"Estimate line search failed", result.Iterations, cost, stepNorm}
+			break
+		}
+	}
+
+	if result.Iterations == opts.MaxIterations {
+		result.Reason = MaxIterations
+		err = NonConvergenceError{"Estimate did not converge", result.Iterations, cost, stepNorm}
+
+		// the last step moved theta away from where J was evaluated
+		var jacErr error
+		J, jacErr = observationJacobian(bvp, O, y, observations, opts)
+		if jacErr != nil {
+			return result, jacErr
+		}
+	}
+
+	result.Beta = matrix.MakeDenseCopy(bvp.Beta)
+	result.B = matrix.MakeDenseCopy(bvp.B)
+	result.Cost = cost
+
+	if J != nil && observations > k {
+		JTJinv, invErr := matrix.Product(J.Transpose(), J).Inverse()
+		if invErr != nil {
+			return result, invErr
+		}
+		result.Covariance = matrix.Scaled(JTJinv, 2*cost/float64(observations-k))
+	}
+	return
+}
+
+// Returns the residuals y[i] - O X[i] at the observed mesh points
+func observationResiduals(bvp *BVP, O matrix.MatrixRO, y []matrix.Matrix) (r []*matrix.DenseMatrix) {
+	for i := range y {
+		if y[i] != nil {
+			r = append(r, matrix.Difference(y[i], matrix.Product(O, bvp.X[i])))
+		}
+	}
+	return
+}
+
+// Returns the derivative of the stacked observation residuals with respect to
+// (Beta, b) from the solution sensitivities, falling back to forward
+// differences of the BVP solution when they are unavailable
+func observationJacobian(bvp *BVP, O matrix.MatrixRO, y []matrix.Matrix, observations int, opts EstimateOptions) (J *matrix.DenseMatrix, err error) {
+	dxdbeta, dxdb, err := bvp.Sensitivities()
+	if err != nil {
+		return differenceObservationJacobian(bvp, O, y, observations, opts)
+	}
+
+	J = matrix.Zeros(observations, bvp.ODE.Q+bvp.ODE.P)
+	row := 0
+	for i := range y {
+		if y[i] == nil {
+			continue
+		}
+		J.SetMatrix(row, 0, matrix.Scaled(matrix.Product(O, dxdbeta[i]), -1))
+		J.SetMatrix(row, bvp.ODE.Q, matrix.Scaled(matrix.Product(O, dxdb[i]), -1))
+		row += O.Rows()
+	}
+	return
+}
+
+func differenceObservationJacobian(bvp *BVP, O matrix.MatrixRO, y []matrix.Matrix, observations int, opts EstimateOptions) (J *matrix.DenseMatrix, err error) {
+	theta := parameterVector(bvp)
+	k := theta.Rows()
+
+	xbase := make([]matrix.Matrix, bvp.N)
+	for i := range bvp.X {
+		xbase[i] = matrix.MakeDenseCopy(bvp.X[i])
+	}
+	defer func() {
+		setParameterVector(bvp, theta)
+		bvp.X = xbase
+	}()
+
+	J = matrix.Zeros(observations, k)
+	for j := 0; j < k; j++ {
+		h := opts.DifferenceStep * math.Max(math.Abs(theta.Get(j, 0)), 1)
+
+		perturbed := theta.Copy()
+		perturbed.Set(j, 0, theta.Get(j, 0)+h)
+		setParameterVector(bvp, perturbed)
+
+		bvp.X = make([]matrix.Matrix, bvp.N)
+		for i := range xbase {
+			bvp.X[i] = matrix.MakeDenseCopy(xbase[i])
+		}
+
+		_, err = bvp.SolveWithOptions(opts.Solve)
+		if err != nil {
+			return
+		}
+
+		row := 0
+		for i := range y {
+			if y[i] == nil {
+				continue
+			}
+			dy := matrix.Scaled(matrix.Product(O, matrix.Difference(bvp.X[i], xbase[i])), -1/h)
+			J.SetMatrix(row, j, dy)
+			row += dy.Rows()
+		}
+	}
+	return
+}
+
+// Returns (Beta, b) stacked into a single column
+func parameterVector(bvp *BVP) *matrix.DenseMatrix {
+	theta := matrix.Zeros(bvp.ODE.Q+bvp.ODE.P, 1)
+	theta.SetMatrix(0, 0, matrix.MakeDenseCopy(bvp.Beta))
+	theta.SetMatrix(bvp.ODE.Q, 0, matrix.MakeDenseCopy(bvp.B))
+	return theta
+}
+
+func setParameterVector(bvp *BVP, theta *matrix.DenseMatrix) {
+	bvp.Beta = theta.GetMatrix(0, 0, bvp.ODE.Q, 1).Copy()
+	bvp.B = theta.GetMatrix(bvp.ODE.Q, 0, bvp.ODE.P, 1).Copy()
+}
+
+// Stacks column blocks into a single column
+func stackBlocks(blocks []*matrix.DenseMatrix) *matrix.DenseMatrix {
+	rows := 0
+	for _, b := range blocks {
+		rows += b.Rows()
+	}
+
+	stacked := matrix.Zeros(rows, 1)
+	row := 0
+	for _, b := range blocks {
+		stacked.SetMatrix(row, 0, b)
+		row += b.Rows()
+	}
+	return stacked
+}
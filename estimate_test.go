@@ -0,0 +1,161 @@
+package bvp
+
+import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func testEstimateMattheij(t *testing.T, ode ODE) {
+	MattheijBVP, err := newMattheijBVP(51)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	MattheijBVP.ODE = ode
+
+	err = (&MattheijBVP).Solve()
+
+	if err != nil {
+		t.Errorf("Error solving")
+	}
+
+	O := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0, 1, 0}, 2, 3)
+	y := make([]matrix.Matrix, MattheijBVP.N)
+	for i := range y {
+		if i%2 == 0 {
+			y[i] = matrix.Product(O, MattheijBVP.X[i])
+		}
+	}
+
+	trueBeta := matrix.MakeDenseCopy(MattheijBVP.Beta)
+	trueB := matrix.MakeDenseCopy(MattheijBVP.B)
+
+	MattheijBVP.Beta = matrix.MakeDenseMatrix([]float64{18.5, 2.05}, 2, 1)
+	MattheijBVP.B = matrix.Sum(trueB, matrix.MakeDenseMatrix([]float64{0.1, -0.1, 0.1}, 3, 1))
+
+	result, err := Estimate(&MattheijBVP, O, y, DefaultEstimateOptions())
+
+	if err != nil {
+		t.Errorf("Error estimating: %s", err)
+	}
+
+	if result.Reason != Converged {
+		t.Errorf("Incorrect termination reason, expected %s, got %s", Converged, result.Reason)
+	}
+
+	if !matrix.ApproxEquals(result.Beta, trueBeta, 1e-5) {
+		t.Errorf("Incorrect Beta estimate, expected %v, got %v", trueBeta, result.Beta)
+	}
+
+	if !matrix.ApproxEquals(result.B, trueB, 1e-5) {
+		t.Errorf("Incorrect b estimate, expected %v, got %v", trueB, result.B)
+	}
+
+	if result.Cost > 1e-10 {
+		t.Errorf("Final cost too large, got %g", result.Cost)
+	}
+
+	if len(result.History) == 0 || result.History[0].Cost < result.Cost {
+		t.Errorf("Expected the cost history to decrease")
+	}
+
+	if result.Covariance == nil || result.Covariance.Rows() != 5 || result.Covariance.Cols() != 5 {
+		t.Errorf("Expected a 5 by 5 covariance matrix")
+	} else if math.IsNaN(result.Covariance.Get(0, 0)) {
+		t.Errorf("Covariance is not a number")
+	}
+}
+
+func TestEstimateMattheij(t *testing.T) {
+	testEstimateMattheij(t, MattheijODE)
+}
+
+func TestEstimateMattheijWithoutDfdbeta(t *testing.T) {
+	testEstimateMattheij(t, NewODE(MattheijF, MattheijDfdx, 3, 2))
+}
+
+func TestEstimateChecksObservations(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(11)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	_, err = Estimate(&MattheijBVP, matrix.Eye(3), make([]matrix.Matrix, 5), DefaultEstimateOptions())
+
+	if err == nil {
+		t.Errorf("Incorrect observation length check. Incorrect length not detected.")
+	}
+}
+
+func TestEstimateMaxIterations(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(51)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	O := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0, 1, 0}, 2, 3)
+	y := make([]matrix.Matrix, MattheijBVP.N)
+	for i := range y {
+		y[i] = matrix.Product(O, MattheijBVP.X[i])
+	}
+	MattheijBVP.Beta = matrix.MakeDenseMatrix([]float64{18.5, 2.05}, 2, 1)
+
+	opts := DefaultEstimateOptions()
+	opts.MaxIterations = 1
+	result, err := Estimate(&MattheijBVP, O, y, opts)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) || result.Reason != MaxIterations {
+		t.Errorf("Expected a NonConvergenceError at the iteration limit, got %v with reason %s", err, result.Reason)
+	}
+
+	// the covariance is from the Jacobian at the final estimates
+	J, err := observationJacobian(&MattheijBVP, O, y, 2*MattheijBVP.N, opts)
+	if err != nil {
+		t.Errorf("Error evaluating Jacobian: %s", err)
+		return
+	}
+	JTJinv, err := matrix.Product(J.Transpose(), J).Inverse()
+	if err != nil {
+		t.Errorf("Error inverting: %s", err)
+		return
+	}
+	expected := matrix.Scaled(JTJinv, 2*result.Cost/float64(2*MattheijBVP.N-5))
+	if result.Covariance == nil || !matrix.ApproxEquals(result.Covariance, expected, 1e-8*expected.DenseMatrix().TwoNorm()) {
+		t.Errorf("Covariance is not evaluated at the final estimates")
+	}
+}
+
+func TestEstimateLineSearchFailed(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(51)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	O := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0, 1, 0}, 2, 3)
+	y := make([]matrix.Matrix, MattheijBVP.N)
+	for i := range y {
+		y[i] = matrix.Product(O, MattheijBVP.X[i])
+	}
+	MattheijBVP.Beta = matrix.MakeDenseMatrix([]float64{18.5, 2.05}, 2, 1)
+
+	opts := DefaultEstimateOptions()
+	opts.MaxLineSearch = 0
+	result, err := Estimate(&MattheijBVP, O, y, opts)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) || result.Reason != LineSearchFailed {
+		t.Errorf("Expected a NonConvergenceError from the line search, got %v with reason %s", err, result.Reason)
+	}
+
+	// the estimates are left at the starting point
+	if result.Beta.Get(0, 0) != 18.5 || result.Beta.Get(1, 0) != 2.05 {
+		t.Errorf("Expected the starting Beta after a failed line search, got %v", result.Beta)
+	}
+}
@@ -0,0 +1,140 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"sort"
+)
+
+// An event is a zero of G along the solution of an initial value problem,
+// such as a component crossing a threshold
+type Event struct {
+	G func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) float64
+
+	// 1 to record only zeros where G is increasing, -1 for only decreasing
+	// and 0 for both
+	Direction int
+
+	Terminal bool // stop the integration at the first recorded zero
+}
+
+// A located event
+type EventRecord struct {
+	Index int // index of the event in IVPOptions.Events
+	T     float64
+	X     *matrix.DenseMatrix
+}
+
+// The values of the event functions at the last accepted step
+type eventTracker struct {
+	events []Event
+	g      []float64
+}
+
+func newEventTracker(events []Event, x matrix.MatrixRO, t float64, beta matrix.MatrixRO) *eventTracker {
+	tr := &eventTracker{events, make([]float64, len(events))}
+	for k, ev := range events {
+		tr.g[k] = ev.G(x, t, beta)
+	}
+	return tr
+}
+
+// Records an accepted step and checks it for events. Returns true if a
+// terminal event occurred, in which case the step is cut back to end at it.
+func (sol *IVPSolution) accept(ode *ODE, t float64, x, f matrix.Matrix, beta matrix.MatrixRO, tr *eventTracker) (stop bool, err error) {
+	sol.T = append(sol.T, t)
+	sol.X = append(sol.X, x)
+	sol.F = append(sol.F, f)
+	sol.Steps++
+
+	if len(tr.events) == 0 {
+		return
+	}
+
+	n := len(sol.T)
+	t0 := sol.T[n-2]
+
+	var found []EventRecord
+	for k, ev := range tr.events {
+		g0, g1 := tr.g[k], ev.G(x, t, beta)
+		tr.g[k] = g1
+
+		if !eventCrossed(g0, g1, ev.Direction) {
+			continue
+		}
+
+		g := func(s float64) float64 {
+			return ev.G(sol.At(s), s, beta)
+		}
+
+		te := locateEvent(g, t0, t, g0, g1)
+		found = append(found, EventRecord{k, te, sol.At(te)})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return math.Abs(found[i].T-t0) < math.Abs(found[j].T-t0)
+	})
+
+	for _, rec := range found {
+		sol.Events = append(sol.Events, rec)
+
+		if tr.events[rec.Index].Terminal {
+			fe, err := sol.evaluate(ode, rec.X, rec.T, beta)
+			if err != nil {
+				return false, err
+			}
+
+			sol.T[n-1], sol.X[n-1], sol.F[n-1] = rec.T, rec.X, fe
+			sol.Terminated = true
+			return true, nil
+		}
+	}
+	return
+}
+
+// Reports whether G went from g0 to g1 through zero in the given direction.
+// A zero at the start of a step is not counted again.
+func eventCrossed(g0, g1 float64, direction int) bool {
+	if g0 == 0 || (g1 != 0 && sign(g0) == sign(g1)) {
+		return false
+	}
+
+	switch {
+	case direction > 0:
+		return g1 > g0
+	case direction < 0:
+		return g1 < g0
+	}
+	return true
+}
+
+// Locates a zero of g between ta and tb, where g changes sign, by the
+// Illinois variant of regula falsi. Returns a time on the far side of the
+// zero from ta.
+func locateEvent(g func(t float64) float64, ta, tb, ga, gb float64) float64 {
+	side := 0
+
+	for k := 0; k < 100 && gb != 0; k++ {
+		if math.Abs(tb-ta) <= 4*machineEpsilon*math.Max(math.Max(math.Abs(ta), math.Abs(tb)), 1) {
+			break
+		}
+
+		tc := (ta*gb - tb*ga) / (gb - ga)
+		gc := g(tc)
+
+		if sign(gc) == sign(gb) || gc == 0 {
+			tb, gb = tc, gc
+			if side == -1 {
+				ga = ga / 2
+			}
+			side = -1
+		} else {
+			ta, ga = tc, gc
+			if side == 1 {
+				gb = gb / 2
+			}
+			side = 1
+		}
+	}
+	return tb
+}
@@ -0,0 +1,115 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+// zeros of the first component, which is sin(t) for the oscillator
+var zeroCrossing = func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) float64 {
+	return x.Get(0, 0)
+}
+
+func TestEventDirection(t *testing.T) {
+	for _, method := range []IVPMethod{DormandPrince54, BogackiShampine32, BDF, Rosenbrock23} {
+		opts := DefaultIVPOptions()
+		opts.Method = method
+		opts.AbsTol, opts.RelTol = 1e-10, 1e-8
+		opts.Events = []Event{
+			{zeroCrossing, 0, false},
+			{zeroCrossing, -1, false},
+			{zeroCrossing, 1, false},
+		}
+
+		sol, err := Integrate(&oscillatorODE, matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), 0, 7, matrix.Zeros(1, 1), opts)
+		if err != nil {
+			t.Errorf("%s: error integrating: %s", method, err)
+			continue
+		}
+
+		expected := []struct {
+			index int
+			t     float64
+		}{
+			{0, math.Pi}, {1, math.Pi}, {0, 2 * math.Pi}, {2, 2 * math.Pi},
+		}
+
+		if len(sol.Events) != len(expected) {
+			t.Errorf("%s: expected %d events, got %d", method, len(expected), len(sol.Events))
+			continue
+		}
+
+		for i, e := range expected {
+			rec := sol.Events[i]
+			if rec.Index != e.index || math.Abs(rec.T-e.t) > 1e-4 {
+				t.Errorf("%s: event %d is %d at t = %f, expected %d at t = %f", method, i, rec.Index, rec.T, e.index, e.t)
+			}
+		}
+
+		if sol.Terminated || sol.T[len(sol.T)-1] != 7 {
+			t.Errorf("%s: expected the integration to reach t = 7", method)
+		}
+	}
+}
+
+func TestTerminalEvent(t *testing.T) {
+	opts := DefaultIVPOptions()
+	opts.Events = []Event{{
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) float64 {
+			return x.Get(0, 0) - 0.5
+		},
+		1, true,
+	}}
+
+	bvp, err := newOscillatorMultipointBVP(21, []int{0, 20})
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	sol, err := bvp.SolveIVPWithOptions(matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), opts)
+	if err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	end := sol.T[len(sol.T)-1]
+	if !sol.Terminated || len(sol.Events) != 1 || math.Abs(end-math.Pi/6) > 1e-5 {
+		t.Errorf("Expected to stop at t = %f, stopped at %f", math.Pi/6, end)
+		return
+	}
+
+	if e := math.Abs(sol.Events[0].X.Get(0, 0) - 0.5); e > 1e-8 {
+		t.Errorf("Event state error %g", e)
+	}
+
+	for i := range bvp.T {
+		if bvp.T[i] > end && math.Abs(bvp.X[i].Get(0, 0)-0.5) > 1e-8 {
+			t.Errorf("Expected X to be held at the event beyond it, got %f at t = %f", bvp.X[i].Get(0, 0), bvp.T[i])
+			break
+		}
+	}
+}
+
+func TestLorenzPoincareSection(t *testing.T) {
+	opts := DefaultIVPOptions()
+	opts.Events = []Event{{zeroCrossing, 1, false}}
+
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+	sol, err := Integrate(&LorenzODE, matrix.MakeDenseMatrix([]float64{1, 1, 30}, 3, 1), 0, 20, beta, opts)
+	if err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	if len(sol.Events) == 0 {
+		t.Errorf("Expected crossings of x = 0")
+	}
+
+	for _, rec := range sol.Events {
+		if math.Abs(rec.X.Get(0, 0)) > 1e-6 {
+			t.Errorf("Event at t = %f has x = %g", rec.T, rec.X.Get(0, 0))
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+// The Newton matrix of the discrete BVP after rightOrthogonalFactorisation,
+// kept so that it can be applied to several right hand sides
+type factorisation struct {
+	A, B, C, D, U []*matrix.DenseMatrix
+
+	// end system coupling x(t_n) and x(t_1)
+	//  [B(n-1) D(n-1)] [x(t_n)]
+	//  [  Bb     Ba  ] [x(t_1)]
+	// where Ba and Bb are the boundary Jacobians, or the rows from
+	// multipointEnds for multipoint conditions. With free parameters a
+	// column block for them is appended on the right.
+	ends *matrix.DenseMatrix
+
+	multipoint *MultipointBoundaryCondition
+
+	// E[k] is column k of the derivative of the interval residuals with
+	// respect to the free parameters, after rqTransformation
+	E [][]*matrix.DenseMatrix
+
+	n, m, r int
+}
+
+func factorise(bvp *BVP) (f *factorisation, err error) {
+	A, B, err := ConstraintMatrixBlocks(bvp)
+	if err != nil {
+		return
+	}
+
+	n, m, r := bvp.N, bvp.ODE.P, len(bvp.FreeParameters)
+
+	if r > 0 && bvp.Multipoint != nil {
+		return nil, MatrixError("Free parameters are not supported with multipoint boundary conditions")
+	}
+
+	f = factoriseBlocks(A, B, n, m, r)
+	f.multipoint = bvp.Multipoint
+
+	if i := f.singularPivot(); i >= 0 {
+		return nil, SingularSystemError{i + 1}
+	}
+	ends := f.ends
+
+	var Ba, Bb *matrix.DenseMatrix
+	if bvp.Multipoint != nil {
+		Ba, Bb = f.multipointEnds(bvp.Multipoint)
+	} else {
+		Ba, Bb, err = bvp.boundaryJacobian()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ends.SetMatrix(m, 0, Bb)
+	ends.SetMatrix(m, m, Ba)
+
+	if r > 0 {
+		E, err := parameterBlocks(bvp, bvp.FreeParameters)
+		if err != nil {
+			return nil, err
+		}
+
+		G, err := boundaryParameterJacobian(bvp, bvp.FreeParameters)
+		if err != nil {
+			return nil, err
+		}
+
+		f.E = make([][]*matrix.DenseMatrix, r)
+		for k := 0; k < r; k++ {
+			f.E[k] = make([]*matrix.DenseMatrix, n)
+			for i := 0; i < n-1; i++ {
+				f.E[k][i] = E[i].GetColVector(k)
+			}
+			f.E[k][n-1] = matrix.Zeros(m, 1)
+
+			rqTransformation(f.A, f.B, f.U, f.E[k], n, m)
+
+			ends.SetMatrix(0, 2*m+k, f.E[k][n-2])
+		}
+		ends.SetMatrix(m, 2*m, G)
+	}
+
+	return
+}
+
+// Factorises the interval blocks A and B of a system on n points, leaving
+// the boundary rows of the end system to be filled in by the caller
+func factoriseBlocks(A, B []*matrix.DenseMatrix, n, m, r int) *factorisation {
+	C, D, U := rightOrthogonalFactorisation(A, B, n, m)
+
+	ends := matrix.Zeros(m*2+r, m*2+r)
+	ends.SetMatrix(0, 0, B[n-2])
+	ends.SetMatrix(0, m, D[n-2])
+
+	return &factorisation{A, B, C, D, U, ends, nil, nil, n, m, r}
+}
+
+// Returns the first i for which the diagonal U[i], the pivot of the unknown
+// i+1 in back substitution, has a zero entry, or -1 if there is none
+func (f *factorisation) singularPivot() int {
+	for i, u := range f.U {
+		for j := 0; j < f.m; j++ {
+			if u.Get(j, 0) == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Solves the Newton system for right hand side blocks c, one per interval
+// followed by the boundary conditions. c is overwritten. With free parameters
+// delta has an extra final block holding their step.
+func (f *factorisation) solve(c []*matrix.DenseMatrix) (delta []*matrix.DenseMatrix, err error) {
+	n, m, r := f.n, f.m, f.r
+
+	rqTransformation(f.A, f.B, f.U, c, n, m)
+
+	smallc := matrix.Zeros(m*2+r, 1)
+	smallc.SetMatrix(0, 0, c[n-2])
+	if f.multipoint != nil {
+		smallc.SetMatrix(m, 0, matrix.Difference(c[n-1], f.multipointOffset(f.multipoint, c)))
+	} else {
+		smallc.SetMatrix(m, 0, c[n-1])
+	}
+
+	// the LU solve divides by a zero pivot rather than failing
+	deltaends, err := f.ends.SolveDense(smallc)
+	if err != nil || !isFinite(deltaends) && isFinite(f.ends) && isFinite(smallc) {
+		return nil, SingularSystemError{n - 1}
+	}
+
+	delta = make([]*matrix.DenseMatrix, n, n+1)
+
+	for i := 0; i < n; i++ {
+		delta[i] = matrix.Zeros(m, 1)
+	}
+
+	delta[0].SetMatrix(0, 0, deltaends.GetMatrix(m, 0, m, 1))
+	delta[n-1].SetMatrix(0, 0, deltaends.GetMatrix(0, 0, m, 1))
+
+	if r > 0 {
+		dbeta := deltaends.GetMatrix(2*m, 0, r, 1).Copy()
+		delta = append(delta, dbeta)
+
+		// move the free parameter terms to the right hand side
+		for k := 0; k < r; k++ {
+			for i := 0; i < n-2; i++ {
+				c[i].Subtract(matrix.Scaled(f.E[k][i], dbeta.Get(k, 0)))
+			}
+		}
+	}
+
+	rightBackSubstitute(f.B, f.C, f.D, f.U, c, delta, n, m)
+
+	return
+}
+
+// Solves J^T y = c for the Newton matrix J, where c has one block per mesh
+// point followed, with free parameters, by one for them, and y has one block
+// per interval followed by the boundary conditions. c is not changed.
+func (f *factorisation) solveTranspose(c []*matrix.DenseMatrix) (y []*matrix.DenseMatrix, err error) {
+	n, m, r := f.n, f.m, f.r
+
+	// the columns of x(t_n), x(t_1) and the free parameters, as in f.ends
+	ends := matrix.Zeros(2*m+r, 1)
+	ends.SetMatrix(0, 0, c[n-1])
+	ends.SetMatrix(m, 0, c[0])
+	if r > 0 {
+		ends.SetMatrix(2*m, 0, c[n])
+	}
+
+	w, rest := f.forwardTranspose(c, nil)
+	ends.Subtract(rest)
+
+	// multipliers of the last interval and of the boundary conditions
+	var last, yb *matrix.DenseMatrix
+
+	if f.multipoint == nil {
+		we, err := f.ends.Transpose().SolveDense(ends)
+		if err != nil || !isFinite(we) {
+			return nil, SingularSystemError{n - 1}
+		}
+		last, yb = we.GetMatrix(0, 0, m, 1).Copy(), we.GetMatrix(m, 0, m+r, 1).Copy()
+	} else {
+		// the multipoint conditions couple interior columns, so w is found
+		// for each unit yb and superposed
+		M := matrix.Zeros(2*m, 2*m)
+		M.SetMatrix(0, 0, f.ends.GetMatrix(0, 0, m, m).Transpose())
+		M.SetMatrix(m, 0, f.ends.GetMatrix(0, m, m, m).Transpose())
+
+		zero := make([]*matrix.DenseMatrix, n)
+		for i := range zero {
+			zero[i] = matrix.Zeros(m, 1)
+		}
+
+		unit := make([][]*matrix.DenseMatrix, m)
+		for l := 0; l < m; l++ {
+			e := matrix.Zeros(m, 1)
+			e.Set(l, 0, 1)
+
+			var column *matrix.DenseMatrix
+			unit[l], column = f.forwardTranspose(zero, e)
+			column.SetMatrix(0, 0, matrix.Sum(column.GetMatrix(0, 0, m, 1), f.multipointColumn(n-1, e)))
+			column.SetMatrix(m, 0, matrix.Sum(column.GetMatrix(m, 0, m, 1), f.multipointColumn(0, e)))
+			M.SetMatrix(0, m+l, column)
+		}
+
+		we, err := M.SolveDense(ends)
+		if err != nil || !isFinite(we) {
+			return nil, SingularSystemError{n - 1}
+		}
+		last, yb = we.GetMatrix(0, 0, m, 1).Copy(), we.GetMatrix(m, 0, m, 1).Copy()
+
+		for l := 0; l < m; l++ {
+			for i := range w {
+				w[i].Add(matrix.Scaled(unit[l][i], yb.Get(l, 0)))
+			}
+		}
+	}
+
+	y = append(w, last, yb)
+	rqTransformationTranspose(f.A, f.B, f.U, y, n, m)
+	return
+}
+
+// Forward substitution with the transposed triangular rows of the first n-2
+// intervals, given the multipliers yb of the multipoint conditions or nil.
+// Returns their multipliers w and the remaining terms of the columns of
+// x(t_n), x(t_1) and the free parameters, stacked as in f.ends.
+func (f *factorisation) forwardTranspose(c []*matrix.DenseMatrix, yb *matrix.DenseMatrix) (w []*matrix.DenseMatrix, rest *matrix.DenseMatrix) {
+	n, m, r := f.n, f.m, f.r
+
+	w = make([]*matrix.DenseMatrix, n-2, n)
+	xn, x1 := matrix.Zeros(m, 1), matrix.Zeros(m, 1)
+	rest = matrix.Zeros(2*m+r, 1)
+
+	for i := 0; i < n-2; i++ {
+		rhs := matrix.MakeDenseCopy(c[i+1])
+		if i > 0 {
+			rhs.Subtract(matrix.Product(f.C[i-1].Transpose(), w[i-1]))
+		}
+		if yb != nil {
+			rhs.Subtract(f.multipointColumn(i+1, yb))
+		}
+
+		// the row block has diagonal U[i] and strict upper triangle B[i]
+		w[i] = matrix.Zeros(m, 1)
+		for j := 0; j < m; j++ {
+			s := rhs.Get(j, 0)
+			for l := 0; l < j; l++ {
+				s -= f.B[i].Get(l, j) * w[i].Get(l, 0)
+			}
+			w[i].Set(j, 0, s/f.U[i].Get(j, 0))
+		}
+
+		x1.Add(matrix.Product(f.D[i].Transpose(), w[i]))
+		for k := 0; k < r; k++ {
+			rest.Set(2*m+k, 0, rest.Get(2*m+k, 0)+matrix.Product(f.E[k][i].Transpose(), w[i]).Get(0, 0))
+		}
+	}
+
+	if n > 2 {
+		xn = matrix.Product(f.C[n-3].Transpose(), w[n-3])
+	}
+
+	rest.SetMatrix(0, 0, xn)
+	rest.SetMatrix(m, 0, x1)
+	return
+}
+
+// Returns the column of x(T[j]) in the multipoint conditions applied to yb,
+// sum over k with Indices[k] = j of B[k]^T yb
+func (f *factorisation) multipointColumn(j int, yb *matrix.DenseMatrix) *matrix.DenseMatrix {
+	column := matrix.Zeros(f.m, 1)
+	for k, index := range f.multipoint.Indices {
+		if index == j {
+			column.Add(matrix.Product(matrix.MakeDenseCopy(f.multipoint.B[k]).Transpose(), yb))
+		}
+	}
+	return column
+}
+
+// Returns the sign of the determinant of the Newton matrix, up to a factor
+// of -1 which depends only on the number of mesh points and variables. The
+// reflections of rightOrthogonalFactorisation leave a block triangular matrix
+// with diagonal U and the end system.
+func (f *factorisation) determinantSign() float64 {
+	s := sign(f.ends.Det())
+	for _, u := range f.U {
+		for j := 0; j < f.m; j++ {
+			s *= sign(u.Get(j, 0))
+		}
+	}
+	return s
+}
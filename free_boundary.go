@@ -0,0 +1,115 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// The interval [Start, Start + L] of a BVP with unknown length L, stored as
+// the final entry of Beta
+type freeBoundary struct {
+	start float64
+	index int // index of L in Beta
+}
+
+// Creates a BVP on [timeMesh[0], timeMesh[0] + L] where the length L is
+// unknown, with its initial guess taken from timeMesh. The problem is solved
+// on the fixed mesh (timeMesh - timeMesh[0]) / L on [0, 1] with F scaled by L,
+// and L is appended to Beta as a free parameter. bc must give P + 1
+// conditions, and is passed Beta with L appended. Use PhysicalMesh for the
+// mesh in the original time.
+func NewFreeBoundaryBVP(ode ODE, initialGuess []matrix.Matrix, timeMesh []float64, bc BoundaryCondition, beta matrix.Matrix) (BVP, error) {
+	n := len(timeMesh)
+
+	if n < 2 || timeMesh[n-1] <= timeMesh[0] {
+		return BVP{}, MatrixError("Free boundary time mesh must be increasing")
+	}
+
+	if beta.Rows() != ode.Q || beta.Cols() != 1 {
+		return BVP{}, NewDimensionErrorIn("NewFreeBoundaryBVP", "beta", ode.Q, 1, beta.Rows(), beta.Cols())
+	}
+
+	start, length := timeMesh[0], timeMesh[n-1]-timeMesh[0]
+
+	normalised := make([]float64, n)
+	for i := range timeMesh {
+		normalised[i] = (timeMesh[i] - start) / length
+	}
+
+	augmented := matrix.Zeros(ode.Q+1, 1)
+	for j := 0; j < ode.Q; j++ {
+		augmented.Set(j, 0, beta.Get(j, 0))
+	}
+	augmented.Set(ode.Q, 0, length)
+
+	bvp, err := NewBVPWithFreeParameters(scaledODE(ode, start), initialGuess, normalised, bc, augmented, []int{ode.Q})
+	if err != nil {
+		return bvp, err
+	}
+
+	bvp.freeBoundary = &freeBoundary{start, ode.Q}
+	return bvp, nil
+}
+
+// Returns the mesh in the original time. This is T unless the BVP was created
+// by NewFreeBoundaryBVP.
+func (bvp *BVP) PhysicalMesh() []float64 {
+	mesh := make([]float64, bvp.N)
+	copy(mesh, bvp.T)
+
+	if bvp.freeBoundary != nil {
+		length := bvp.Beta.Get(bvp.freeBoundary.index, 0)
+		for i := range mesh {
+			mesh[i] = bvp.freeBoundary.start + length*mesh[i]
+		}
+	}
+	return mesh
+}
+
+// Returns the ODE dx/ds = L F(x, start + L s, beta) on s in [0, 1], where L
+// is appended to beta. dfdbeta uses the analytic dfdbeta of the ODE when it
+// has one, and only dF/dt is differenced.
+func scaledODE(ode ODE, start float64) ODE {
+	q := ode.Q
+
+	split := func(beta matrix.MatrixRO) (matrix.MatrixRO, float64) {
+		return matrix.MakeDenseCopy(beta).GetMatrix(0, 0, q, 1), beta.Get(q, 0)
+	}
+
+	f := func(x matrix.MatrixRO, s float64, beta matrix.MatrixRO) matrix.Matrix {
+		b, length := split(beta)
+		return matrix.Scaled(ode.f(x, start+length*s, b), length)
+	}
+
+	dfdx := func(x matrix.MatrixRO, s float64, beta matrix.MatrixRO) matrix.Matrix {
+		b, length := split(beta)
+		if ode.dfdx == nil {
+			return matrix.Scaled(ode.difference(x, start+length*s, b, false), length)
+		}
+		return matrix.Scaled(ode.dfdx(x, start+length*s, b), length)
+	}
+
+	// d/dbeta is L dfdbeta, and d/dL is f + L s df/dt since t depends on L
+	dfdbeta := func(x matrix.MatrixRO, s float64, beta matrix.MatrixRO) matrix.Matrix {
+		b, length := split(beta)
+		t := start + length*s
+
+		J := matrix.Zeros(ode.P, q+1)
+		if q > 0 {
+			if ode.dfdbeta == nil {
+				J.SetMatrix(0, 0, matrix.Scaled(ode.difference(x, t, b, true), length))
+			} else {
+				J.SetMatrix(0, 0, matrix.Scaled(ode.dfdbeta(x, t, b), length))
+			}
+		}
+
+		h := math.Cbrt(machineEpsilon) * math.Max(math.Abs(t), 1)
+		dfdt := matrix.Scaled(matrix.Difference(ode.f(x, t+h, b), ode.f(x, t-h, b)), 1/(2*h))
+		J.SetMatrix(0, q, matrix.Sum(ode.f(x, t, b), matrix.Scaled(dfdt, length*s)))
+		return J
+	}
+
+	scaled := NewODEWithParamJacobian(f, dfdx, dfdbeta, ode.P, q+1)
+	scaled.Differencing = ode.Differencing
+	return scaled
+}
@@ -0,0 +1,117 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestFreeBoundary(t *testing.T) {
+	bvp, err := newTimeToTargetBVP(101)
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	result, err := bvp.SolveWithOptions(DefaultSolveOptions())
+
+	if err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+	}
+
+	mesh := bvp.PhysicalMesh()
+
+	if e := math.Abs(mesh[bvp.N-1] - math.Pi/6); e > 1e-4 {
+		t.Errorf("Incorrect final time, expected %g, got %g", math.Pi/6, mesh[bvp.N-1])
+	}
+
+	if result.FreeParameters.Get(0, 0) != mesh[bvp.N-1] {
+		t.Errorf("Interval length not reported")
+	}
+
+	if mesh[0] != 0 || bvp.T[bvp.N-1] != 1 {
+		t.Errorf("Incorrect mesh mapping")
+	}
+
+	for i := range bvp.X {
+		if math.Abs(bvp.X[i].Get(0, 0)-math.Sin(mesh[i])) > 1e-4 {
+			t.Errorf("Incorrect solution at t = %g", mesh[i])
+			break
+		}
+	}
+}
+
+func TestPhysicalMeshFixedInterval(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(11)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	mesh := MattheijBVP.PhysicalMesh()
+
+	for i := range mesh {
+		if mesh[i] != MattheijBVP.T[i] {
+			t.Errorf("Physical mesh differs from T on a fixed interval")
+			break
+		}
+	}
+}
+
+func TestScaledODEDfdbeta(t *testing.T) {
+	calls := 0
+	ode := NewODEWithParamJacobian(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.MakeDenseMatrix([]float64{beta.Get(0, 0) * t * x.Get(0, 0)}, 1, 1)
+		},
+		nil,
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			calls++
+			return matrix.MakeDenseMatrix([]float64{t * x.Get(0, 0)}, 1, 1)
+		},
+		1, 1,
+	)
+
+	scaled := scaledODE(ode, 0.5)
+	x := matrix.MakeDenseMatrix([]float64{2}, 1, 1)
+	beta := matrix.MakeDenseMatrix([]float64{3, 1.5}, 2, 1)
+	s := 0.4
+
+	J, err := scaled.Dfdbeta(x, s, beta)
+	if err != nil {
+		t.Errorf("Error evaluating Dfdbeta: %s", err)
+		return
+	}
+
+	reference, err := centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		return scaled.F(x, s, v)
+	}, beta)
+	if err != nil {
+		t.Errorf("Error differencing F: %s", err)
+		return
+	}
+
+	if !matrix.ApproxEquals(J, reference, 1e-6) {
+		t.Errorf("Scaled Dfdbeta %v disagrees with differences %v", J, reference)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected the analytic dfdbeta to be used once, got %d calls", calls)
+	}
+
+	// the phase condition ODE keeps it, with a zero row for z
+	augmented := phaseODE(scaled, func(s float64) *matrix.DenseMatrix {
+		return matrix.Ones(1, 1)
+	})
+
+	Jaug, err := augmented.Dfdbeta(matrix.MakeDenseMatrix([]float64{2, 0}, 2, 1), s, beta)
+	if err != nil {
+		t.Errorf("Error evaluating Dfdbeta: %s", err)
+		return
+	}
+
+	if calls != 2 || !matrix.ApproxEquals(Jaug.DenseMatrix().GetMatrix(0, 0, 1, 2), J, 1e-14) || Jaug.Get(1, 0) != 0 || Jaug.Get(1, 1) != 0 {
+		t.Errorf("Phase condition Dfdbeta %v does not extend %v", Jaug, J)
+	}
+}
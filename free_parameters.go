@@ -0,0 +1,124 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+// Creates a BVP in which the entries Beta[free[k]] are unknowns determined
+// along with X, as for eigenvalue problems. bc must give one extra equation
+// per free parameter, P + len(free) in all. B0, B1 and B are left nil.
+func NewBVPWithFreeParameters(ode ODE, initialGuess []matrix.Matrix, timeMesh []float64, bc BoundaryCondition, beta matrix.Matrix, free []int) (BVP, error) {
+	var bvp BVP
+
+	n := len(timeMesh)
+
+	if err := checkInitialGuess("NewBVPWithFreeParameters", ode, initialGuess, n); err != nil {
+		return bvp, err
+	}
+
+	if beta.Rows() != ode.Q || beta.Cols() != 1 {
+		return bvp, NewDimensionErrorIn("NewBVPWithFreeParameters", "beta", ode.Q, 1, beta.Rows(), beta.Cols())
+	}
+
+	seen := make(map[int]bool)
+	for _, j := range free {
+		if j < 0 || j >= ode.Q || seen[j] {
+			return bvp, MatrixError("Free parameter indices must be distinct indices into beta")
+		}
+		seen[j] = true
+	}
+
+	indices := make([]int, len(free))
+	copy(indices, free)
+
+	bvp = BVP{ODE: ode, X: initialGuess, T: timeMesh, Beta: beta, N: n, BC: bc, FreeParameters: indices}
+
+	// check the dimensions of bc at the initial guess
+	if _, err := bvp.boundaryResidual(); err != nil {
+		return BVP{}, err
+	}
+	if _, _, err := bvp.boundaryJacobian(); err != nil {
+		return BVP{}, err
+	}
+
+	return bvp, nil
+}
+
+// Returns the free entries of Beta as a column
+func (bvp *BVP) freeParameterVector() *matrix.DenseMatrix {
+	v := matrix.Zeros(len(bvp.FreeParameters), 1)
+	for k, j := range bvp.FreeParameters {
+		v.Set(k, 0, bvp.Beta.Get(j, 0))
+	}
+	return v
+}
+
+// Returns X followed by the free entries of Beta, matching the blocks of the
+// Newton step
+func (bvp *BVP) unknowns() []matrix.Matrix {
+	if len(bvp.FreeParameters) == 0 {
+		return bvp.X
+	}
+
+	unknowns := make([]matrix.Matrix, bvp.N, bvp.N+1)
+	copy(unknowns, bvp.X)
+	return append(unknowns, bvp.freeParameterVector())
+}
+
+// Sets Beta to betaold with the free entries stepped by -scale times the
+// final block of delta. Beta is unchanged if there are no free parameters.
+func (bvp *BVP) stepFreeParameters(betaold matrix.MatrixRO, delta []*matrix.DenseMatrix, scale float64) {
+	if len(bvp.FreeParameters) == 0 {
+		return
+	}
+
+	beta := matrix.MakeDenseCopy(betaold)
+	for k, j := range bvp.FreeParameters {
+		beta.Set(j, 0, beta.Get(j, 0)-scale*delta[bvp.N].Get(k, 0))
+	}
+	bvp.Beta = beta
+}
+
+// Returns the derivatives of each interval residual with respect to the
+// entries Beta[indices[k]]
+func parameterBlocks(bvp *BVP, indices []int) (E []*matrix.DenseMatrix, err error) {
+	E = make([]*matrix.DenseMatrix, bvp.N-1)
+
+	disc := bvp.discretization()
+
+	for i := 0; i < bvp.N-1; i++ {
+		drdbeta, err := disc.ParameterJacobian(&bvp.ODE, bvp.X[i], bvp.X[i+1], bvp.T[i], bvp.T[i+1], bvp.Beta)
+		if err != nil {
+			return nil, evaluationError("Dfdbeta", i, bvp.T[i], err)
+		}
+
+		E[i] = matrix.Zeros(bvp.ODE.P, len(indices))
+		for k, j := range indices {
+			E[i].SetMatrix(0, k, drdbeta.GetColVector(j))
+		}
+	}
+	return
+}
+
+// Returns the derivative of the boundary residual with respect to the entries
+// Beta[indices[k]], by central differences of BC. Linear and multipoint
+// boundary conditions do not depend on Beta.
+func boundaryParameterJacobian(bvp *BVP, indices []int) (*matrix.DenseMatrix, error) {
+	if bvp.BC == nil || bvp.Multipoint != nil {
+		return matrix.Zeros(bvp.ODE.P+len(bvp.FreeParameters), len(indices)), nil
+	}
+
+	x1, xn := bvp.X[0], bvp.X[bvp.N-1]
+	v := matrix.Zeros(len(indices), 1)
+	for k, j := range indices {
+		v.Set(k, 0, bvp.Beta.Get(j, 0))
+	}
+
+	return centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		beta := matrix.MakeDenseCopy(bvp.Beta)
+		for k, j := range indices {
+			beta.Set(j, 0, v.Get(k, 0))
+		}
+		return bvp.BC.Residual(x1, xn, beta)
+	}, v)
+}
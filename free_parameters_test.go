@@ -0,0 +1,86 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestFreeParameterEigenvalue(t *testing.T) {
+	odes := []struct {
+		name string
+		ode  ODE
+	}{
+		{"analytic", sturmLiouvilleODE},
+		{"difference", NewODE(sturmLiouvilleODE.f, sturmLiouvilleODE.dfdx, 2, 1)},
+	}
+
+	for _, o := range odes {
+		for k := 1; k <= 2; k++ {
+			lambda := float64(k * k)
+
+			bvp, err := newSturmLiouvilleBVP(o.ode, 201, k, 0.8*lambda)
+
+			if err != nil {
+				t.Errorf("%s: error creating BVP: %s", o.name, err)
+				continue
+			}
+
+			result, err := bvp.SolveWithOptions(DefaultSolveOptions())
+
+			if err != nil {
+				t.Errorf("%s: error solving BVP: %s", o.name, err)
+			}
+
+			if result.FreeParameters == nil || result.FreeParameters.Get(0, 0) != bvp.Beta.Get(0, 0) {
+				t.Errorf("%s: free parameter not reported", o.name)
+				continue
+			}
+
+			if e := math.Abs(bvp.Beta.Get(0, 0) - lambda); e > 1e-3*lambda {
+				t.Errorf("%s: eigenvalue %d incorrect, expected %g, got %g", o.name, k, lambda, bvp.Beta.Get(0, 0))
+			}
+
+			for i := range bvp.X {
+				kt := float64(k) * bvp.T[i]
+				if math.Abs(bvp.X[i].Get(0, 0)-math.Sin(kt)/float64(k)) > 1e-3 {
+					t.Errorf("%s: eigenfunction %d incorrect at t = %g", o.name, k, bvp.T[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestChecksFreeParameters(t *testing.T) {
+	_, err := newSturmLiouvilleBVP(sturmLiouvilleODE, 11, 1, 1)
+
+	if err != nil {
+		t.Errorf("Incorrect free parameter check. Correct parameters used.")
+	}
+
+	initialGuess := make([]matrix.Matrix, 11)
+	timeMesh := make([]float64, 11)
+	for i := range initialGuess {
+		initialGuess[i] = matrix.Zeros(2, 1)
+	}
+	beta := matrix.Zeros(1, 1)
+
+	_, err = NewBVPWithFreeParameters(sturmLiouvilleODE, initialGuess, timeMesh, sturmLiouvilleBC, beta, []int{1})
+
+	if err == nil {
+		t.Errorf("Incorrect free parameter check. Index past the end used.")
+	}
+
+	_, err = NewBVPWithFreeParameters(sturmLiouvilleODE, initialGuess, timeMesh, sturmLiouvilleBC, beta, []int{0, 0})
+
+	if err == nil {
+		t.Errorf("Incorrect free parameter check. Repeated index used.")
+	}
+
+	_, err = NewBVPWithFreeParameters(sturmLiouvilleODE, initialGuess, timeMesh, sturmLiouvilleBC, beta, nil)
+
+	if err == nil {
+		t.Errorf("Incorrect free parameter check. Extra boundary condition without a free parameter used.")
+	}
+}
@@ -0,0 +1,181 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+func newMattheijBVP(n int) (BVP, error) {
+	timeMesh := make([]float64, n, n)
+	initialGuess := make([]matrix.Matrix, n, n)
+
+	for i := 0; i < n; i++ {
+		timeMesh[i] = float64(i) / (float64(n) - 1)
+		initialGuess[i] = matrix.Scaled(matrix.Ones(3, 1), math.Exp(timeMesh[i]))
+	}
+
+	B0 := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0, 0, 0, 0, 0, 0}, 3, 3)
+	B1 := matrix.MakeDenseMatrix([]float64{0, 0, 0, 0, 1, 0, 0.8415, 0, 0.5403}, 3, 3)
+	beta := matrix.MakeDenseMatrix([]float64{19, 2}, 2, 1)
+	b := matrix.Sum(matrix.Product(B0, initialGuess[0]), matrix.Product(B1, initialGuess[n-1]))
+
+	return NewBVPWithInitialGuess(MattheijODE, initialGuess, timeMesh, B0, B1, beta, b)
+}
+
+func mattheijMaxError(bvp *BVP) (maxError float64) {
+	for i := 0; i < bvp.N; i++ {
+		for j := 0; j < 3; j++ {
+			maxError = math.Max(maxError, math.Abs(bvp.X[i].Get(j, 0)-math.Exp(bvp.T[i])))
+		}
+	}
+	return
+}
+
+var oscillatorODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -x.Get(0, 0)}, 2, 1)
+	},
+	nil, 2, 1,
+)
+
+func oscillatorMaxError(bvp *BVP) (maxError float64) {
+	for i := 0; i < bvp.N; i++ {
+		maxError = math.Max(maxError, math.Abs(bvp.X[i].Get(0, 0)-math.Sin(bvp.T[i])))
+		maxError = math.Max(maxError, math.Abs(bvp.X[i].Get(1, 0)-math.Cos(bvp.T[i])))
+	}
+	return
+}
+
+// y'' = -y on [0, tf] with y(0) = 0 and y(tf) = sin(tf), which becomes
+// ill-conditioned as tf approaches pi
+func newOscillatorEndsBVP(n int, tf float64) (BVP, error) {
+	timeMesh := make([]float64, n)
+	initialGuess := make([]matrix.Matrix, n)
+	for i := range timeMesh {
+		timeMesh[i] = tf * float64(i) / float64(n-1)
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{math.Sin(timeMesh[i]), math.Cos(timeMesh[i])}, 2, 1)
+	}
+
+	B0 := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0}, 2, 2)
+	B1 := matrix.MakeDenseMatrix([]float64{0, 0, 1, 0}, 2, 2)
+	b := matrix.MakeDenseMatrix([]float64{0, math.Sin(tf)}, 2, 1)
+
+	return NewBVPWithInitialGuess(oscillatorODE, initialGuess, timeMesh, B0, B1, matrix.Zeros(1, 1), b)
+}
+
+// x'' = -x on [0, 2] with x(0) = 0 and x at the mesh point indices[1] fixed,
+// satisfied by the exact solution x = (sin t, cos t)
+func newOscillatorMultipointBVP(n int, indices []int) (BVP, error) {
+	timeMesh := make([]float64, n, n)
+	initialGuess := make([]matrix.Matrix, n, n)
+
+	for i := 0; i < n; i++ {
+		timeMesh[i] = 2 * float64(i) / (float64(n) - 1)
+		initialGuess[i] = matrix.Zeros(2, 1)
+	}
+
+	var tm float64
+	if indices[1] >= 0 && indices[1] < n {
+		tm = timeMesh[indices[1]]
+	}
+
+	bc := MultipointBoundaryCondition{
+		Indices: indices,
+		B: []matrix.Matrix{
+			matrix.MakeDenseMatrix([]float64{1, 0, 0, 0}, 2, 2),
+			matrix.MakeDenseMatrix([]float64{0, 0, 1, 0}, 2, 2),
+		},
+		Rhs: matrix.MakeDenseMatrix([]float64{0, math.Sin(tm)}, 2, 1),
+	}
+
+	return NewMultipointBVP(oscillatorODE, initialGuess, timeMesh, bc, matrix.Zeros(1, 1))
+}
+
+// Bratu's problem y'' + lambda e^y = 0, y(0) = y(1) = 0, with lambda = beta[0].
+// The lower branch from lambda = 0 turns back at a fold near lambda = 3.5138.
+var bratuODE = NewODEWithParamJacobian(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -beta.Get(0, 0) * math.Exp(x.Get(0, 0))}, 2, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, 1, -beta.Get(0, 0) * math.Exp(x.Get(0, 0)), 0}, 2, 2)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, -math.Exp(x.Get(0, 0))}, 2, 1)
+	},
+	2, 1,
+)
+
+const bratuFold = 3.513830719
+
+func newBratuBVP(n int, lambda float64) (BVP, error) {
+	timeMesh := make([]float64, n, n)
+	for i := 0; i < n; i++ {
+		timeMesh[i] = float64(i) / (float64(n) - 1)
+	}
+
+	B0 := matrix.MakeDenseMatrix([]float64{1, 0, 0, 0}, 2, 2)
+	B1 := matrix.MakeDenseMatrix([]float64{0, 0, 1, 0}, 2, 2)
+	beta := matrix.MakeDenseMatrix([]float64{lambda}, 1, 1)
+
+	return NewBVPWithoutInitialGuess(bratuODE, timeMesh, B0, B1, beta, matrix.Zeros(2, 1))
+}
+
+// y'' + lambda y = 0 with lambda = beta[0]
+var sturmLiouvilleODE = NewODEWithParamJacobian(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -beta.Get(0, 0) * x.Get(0, 0)}, 2, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, 1, -beta.Get(0, 0), 0}, 2, 2)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{0, -x.Get(0, 0)}, 2, 1)
+	},
+	2, 1,
+)
+
+// y(0) = 0, y'(0) = 1 and y(pi) = 0
+var sturmLiouvilleBC = NewNonlinearBoundaryCondition(
+	func(xa, xb, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseMatrix([]float64{xa.Get(0, 0), xa.Get(1, 0) - 1, xb.Get(0, 0)}, 3, 1)
+	},
+	nil, 2,
+)
+
+// Returns the eigenvalue problem on [0, pi] starting from the k-th
+// eigenfunction sin(kt)/k scaled by 1.2, with lambda guessed as lambda0
+func newSturmLiouvilleBVP(ode ODE, n, k int, lambda0 float64) (BVP, error) {
+	timeMesh := make([]float64, n, n)
+	initialGuess := make([]matrix.Matrix, n, n)
+
+	for i := 0; i < n; i++ {
+		timeMesh[i] = math.Pi * float64(i) / (float64(n) - 1)
+		kt := float64(k) * timeMesh[i]
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{1.2 * math.Sin(kt) / float64(k), 1.2 * math.Cos(kt)}, 2, 1)
+	}
+
+	beta := matrix.MakeDenseMatrix([]float64{lambda0}, 1, 1)
+
+	return NewBVPWithFreeParameters(ode, initialGuess, timeMesh, sturmLiouvilleBC, beta, []int{0})
+}
+
+// y'' = -y from y(0) = 0, y'(0) = 1 until y reaches 1/2, at time pi/6
+func newTimeToTargetBVP(n int) (BVP, error) {
+	timeMesh := make([]float64, n, n)
+	initialGuess := make([]matrix.Matrix, n, n)
+
+	for i := 0; i < n; i++ {
+		timeMesh[i] = 0.7 * float64(i) / (float64(n) - 1)
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{timeMesh[i], 1}, 2, 1)
+	}
+
+	bc := NewNonlinearBoundaryCondition(
+		func(xa, xb, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.MakeDenseMatrix([]float64{xa.Get(0, 0), xa.Get(1, 0) - 1, xb.Get(0, 0) - 0.5}, 3, 1)
+		},
+		nil, 2,
+	)
+
+	return NewFreeBoundaryBVP(oscillatorODE, initialGuess, timeMesh, bc, matrix.Zeros(1, 1))
+}
@@ -0,0 +1,54 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"sort"
+)
+
+// Evaluates the cubic Hermite interpolant matching x0, f0 at t0 and x1, f1 at t1
+func hermite(x0, f0, x1, f1 matrix.MatrixRO, t0, t1, t float64) *matrix.DenseMatrix {
+	h := t1 - t0
+	s := (t - t0) / h
+
+	return matrix.Sum(
+		matrix.Scaled(x0, (1+2*s)*(1-s)*(1-s)),
+		matrix.Scaled(f0, h*s*(1-s)*(1-s)),
+		matrix.Scaled(x1, s*s*(3-2*s)),
+		matrix.Scaled(f1, h*s*s*(s-1)),
+	)
+}
+
+// Evaluates the time derivative of the cubic Hermite interpolant
+func hermiteDerivative(x0, f0, x1, f1 matrix.MatrixRO, t0, t1, t float64) *matrix.DenseMatrix {
+	h := t1 - t0
+	s := (t - t0) / h
+
+	return matrix.Sum(
+		matrix.Scaled(matrix.Difference(x1, x0), 6*s*(1-s)/h),
+		matrix.Scaled(f0, (1-s)*(1-3*s)),
+		matrix.Scaled(f1, s*(3*s-2)),
+	)
+}
+
+// Returns the index i of the mesh interval [T[i], T[i+1]] containing t,
+// clamped to the first and last intervals
+func meshInterval(T []float64, t float64) int {
+	i := sort.SearchFloat64s(T, t) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i > len(T)-2 {
+		i = len(T) - 2
+	}
+	return i
+}
+
+// Interpolates the mesh function X on T onto the times in tNew using cubic
+// Hermite interpolation with derivatives from ode.F
+func interpolateMesh(ode *ODE, X []matrix.Matrix, T []float64, beta matrix.MatrixRO, tNew []float64) (xNew []matrix.Matrix, err error) {
+	s, err := NewSolution(ode, X, T, beta)
+	if err != nil {
+		return
+	}
+	return s.Resample(tNew), nil
+}
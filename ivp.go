@@ -0,0 +1,488 @@
+package bvp
+
+import (
+	"fmt"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+type IVPMethod int
+
+const (
+	// Dormand-Prince 5(4), the default
+	DormandPrince54 IVPMethod = iota
+
+	// Bogacki-Shampine 3(2), cheaper at loose tolerances
+	BogackiShampine32
+
+	// Variable order backward differentiation formulas of orders 1 to
+	// MaxOrder, for stiff problems
+	BDF
+
+	// The modified Rosenbrock 2(3) triple of Shampine and Reichelt, for
+	// stiff problems at loose tolerances
+	Rosenbrock23
+)
+
+func (m IVPMethod) String() string {
+	switch m {
+	case DormandPrince54:
+		return "Dormand-Prince 5(4)"
+	case BogackiShampine32:
+		return "Bogacki-Shampine 3(2)"
+	case BDF:
+		return "BDF"
+	case Rosenbrock23:
+		return "Rosenbrock 2(3)"
+	}
+	return "unknown"
+}
+
+type IVPFailure int
+
+const (
+	StepSizeTooSmall        IVPFailure = iota // step size fell below MinStep
+	TooManySteps                              // MaxSteps attempted steps taken
+	SingularIterationMatrix                   // the Newton matrix of an implicit method could not be inverted
+	NonFinite                                 // the solution was not finite at any step size above MinStep
+)
+
+func (f IVPFailure) String() string {
+	switch f {
+	case StepSizeTooSmall:
+		return "step size too small"
+	case TooManySteps:
+		return "too many steps"
+	case SingularIterationMatrix:
+		return "singular iteration matrix"
+	case NonFinite:
+		return "non-finite solution"
+	}
+	return "unknown"
+}
+
+// Reports where and why Integrate stopped before reaching t1
+type IVPError struct {
+	Method IVPMethod
+	Reason IVPFailure
+	T      float64 // time reached
+	Step   float64 // size of the last step attempted
+}
+
+func (e IVPError) Error() string {
+	return fmt.Sprintf("%s integrator failed at t = %g with step size %g: %s", e.Method, e.T, e.Step, e.Reason)
+}
+
+// Options for Integrate
+type IVPOptions struct {
+	Method IVPMethod
+
+	// The local error estimate e of each step must satisfy
+	// sqrt(mean((e_j / (AbsTol + RelTol*|x_j|))^2)) <= 1
+	AbsTol, RelTol float64
+
+	InitialStep float64 // first step size, chosen automatically if zero
+	MaxStep     float64 // largest step size, unlimited if zero
+	MinStep     float64 // integration fails if the step falls below this
+	MaxSteps    int     // maximum number of attempted steps
+
+	MaxOrder int // highest order used by BDF, from 1 to 5
+
+	Events []Event // event functions located during the integration
+}
+
+func DefaultIVPOptions() IVPOptions {
+	return IVPOptions{
+		Method:      DormandPrince54,
+		AbsTol:      1e-8,
+		RelTol:      1e-6,
+		InitialStep: 0,
+		MaxStep:     0,
+		MinStep:     1e-12,
+		MaxSteps:    100000,
+		MaxOrder:    5,
+	}
+}
+
+// The accepted steps of an initial value problem, with dense output by the
+// 4th order continuous extension of Dormand and Prince for DormandPrince54,
+// and otherwise by cubic Hermite interpolation between them, which is 3rd
+// order and so less accurate than the steps of a higher order method
+type IVPSolution struct {
+	T []float64       // times of accepted steps, from t0 to t1
+	X []matrix.Matrix // solution at T
+	F []matrix.Matrix // derivative at T
+
+	Steps       int // number of accepted steps
+	Rejected    int // number of rejected steps
+	Evaluations int // number of evaluations of ODE.F
+
+	// work done by the implicit methods
+	Jacobians      int // number of evaluations of ODE.Dfdx
+	Factorisations int // number of iteration matrices inverted
+
+	Events     []EventRecord // events located, in the order they occurred
+	Terminated bool          // stopped early by a terminal event
+
+	// continuous extension of each step, if the method has one
+	dense []*continuousExtension
+}
+
+// Returns the solution at t, which should lie between the first and last
+// entries of T
+func (sol *IVPSolution) At(t float64) *matrix.DenseMatrix {
+	if len(sol.T) < 2 {
+		return matrix.MakeDenseCopy(sol.X[0])
+	}
+
+	i := sol.interval(t)
+	if i < len(sol.dense) {
+		return sol.dense[i].at(t)
+	}
+	return hermite(sol.X[i], sol.F[i], sol.X[i+1], sol.F[i+1], sol.T[i], sol.T[i+1], t)
+}
+
+// Continuous extension of a step of size h from t,
+//
+//	x(t + s h) = r0 + s (r1 + (1-s) (r2 + s (r3 + (1-s) r4)))
+//
+// It is kept whole so that it still applies if a terminal event shortens
+// the step.
+type continuousExtension struct {
+	t, h float64
+	r    [5]*matrix.DenseMatrix
+}
+
+func (c *continuousExtension) at(t float64) *matrix.DenseMatrix {
+	s := (t - c.t) / c.h
+	x := matrix.Scaled(c.r[4], 1-s)
+	x.Add(c.r[3])
+	x.Scale(s)
+	x.Add(c.r[2])
+	x.Scale(1 - s)
+	x.Add(c.r[1])
+	x.Scale(s)
+	x.Add(c.r[0])
+	return x
+}
+
+// Returns the solution at each of the times in mesh, such as BVP.T
+func (sol *IVPSolution) Sample(mesh []float64) []matrix.Matrix {
+	X := make([]matrix.Matrix, len(mesh))
+	for k, t := range mesh {
+		X[k] = sol.At(t)
+	}
+	return X
+}
+
+// Returns the index of the step containing t, for either direction of
+// integration
+func (sol *IVPSolution) interval(t float64) int {
+	n := len(sol.T)
+	if n < 2 {
+		return 0
+	}
+	if sol.T[n-1] >= sol.T[0] {
+		return meshInterval(sol.T, t)
+	}
+
+	reversed := make([]float64, n)
+	for i := range reversed {
+		reversed[i] = -sol.T[i]
+	}
+	return meshInterval(reversed, -t)
+}
+
+// Butcher tableau of an explicit embedded Runge-Kutta pair whose last stage
+// is evaluated at the new solution, so it gives F there for free
+type rungeKuttaTableau struct {
+	c []float64
+	a [][]float64
+	b []float64 // weights of the propagated solution
+	e []float64 // difference between b and the embedded weights
+	q int       // order of the embedded method, for step size control
+
+	// weights of r4 in the continuous extension, nil if there is none
+	d []float64
+}
+
+var dormandPrince54 = rungeKuttaTableau{
+	c: []float64{0, 1. / 5, 3. / 10, 4. / 5, 8. / 9, 1, 1},
+	a: [][]float64{
+		{},
+		{1. / 5},
+		{3. / 40, 9. / 40},
+		{44. / 45, -56. / 15, 32. / 9},
+		{19372. / 6561, -25360. / 2187, 64448. / 6561, -212. / 729},
+		{9017. / 3168, -355. / 33, 46732. / 5247, 49. / 176, -5103. / 18656},
+		{35. / 384, 0, 500. / 1113, 125. / 192, -2187. / 6784, 11. / 84},
+	},
+	b: []float64{35. / 384, 0, 500. / 1113, 125. / 192, -2187. / 6784, 11. / 84, 0},
+	e: []float64{
+		35./384 - 5179./57600, 0, 500./1113 - 7571./16695, 125./192 - 393./640,
+		-2187./6784 + 92097./339200, 11./84 - 187./2100, -1. / 40,
+	},
+	q: 4,
+	d: []float64{
+		-12715105075. / 11282082432, 0, 87487479700. / 32700410799, -10690763975. / 1880347072,
+		701980252875. / 199316789632, -1453857185. / 822651844, 69997945. / 29380423,
+	},
+}
+
+var bogackiShampine32 = rungeKuttaTableau{
+	c: []float64{0, 1. / 2, 3. / 4, 1},
+	a: [][]float64{
+		{},
+		{1. / 2},
+		{0, 3. / 4},
+		{2. / 9, 1. / 3, 4. / 9},
+	},
+	b: []float64{2. / 9, 1. / 3, 4. / 9, 0},
+	e: []float64{2./9 - 7./24, 1./3 - 1./4, 4./9 - 1./3, -1. / 8},
+	q: 2,
+}
+
+// Integrates x' = F(x, t, beta) from x0 at t0 to t1 with adaptive step size
+// control. t1 may be less than t0. If the integration fails the error is an
+// IVPError, and the solution holds the steps taken so far.
+func Integrate(ode *ODE, x0 matrix.MatrixRO, t0, t1 float64, beta matrix.MatrixRO, opts IVPOptions) (sol IVPSolution, err error) {
+	if x0.Rows() != ode.P || x0.Cols() != 1 {
+		return sol, NewDimensionErrorIn("Integrate", "x0", ode.P, 1, x0.Rows(), x0.Cols())
+	}
+
+	if opts.AbsTol <= 0 && opts.RelTol <= 0 {
+		return sol, MatrixError("IVP tolerances must not both be zero")
+	}
+
+	x := matrix.MakeDenseCopy(x0)
+
+	switch opts.Method {
+	case DormandPrince54:
+		return integrateRungeKutta(ode, dormandPrince54, x, t0, t1, beta, opts)
+	case BogackiShampine32:
+		return integrateRungeKutta(ode, bogackiShampine32, x, t0, t1, beta, opts)
+	case BDF:
+		if opts.MaxOrder < 1 || opts.MaxOrder > maxBDFOrder {
+			return sol, MatrixError("BDF order must be between 1 and 5")
+		}
+		return integrateBDF(ode, x, t0, t1, beta, opts)
+	case Rosenbrock23:
+		return integrateRosenbrock(ode, x, t0, t1, beta, opts)
+	}
+	return sol, MatrixError("Unknown IVP method")
+}
+
+func integrateRungeKutta(ode *ODE, tableau rungeKuttaTableau, x *matrix.DenseMatrix, t0, t1 float64, beta matrix.MatrixRO, opts IVPOptions) (sol IVPSolution, err error) {
+	direction := sign(t1 - t0)
+	span := math.Abs(t1 - t0)
+
+	f, err := sol.evaluate(ode, x, t0, beta)
+	if err != nil {
+		return
+	}
+
+	sol.T = []float64{t0}
+	sol.X = []matrix.Matrix{x}
+	sol.F = []matrix.Matrix{f}
+
+	events := newEventTracker(opts.Events, x, t0, beta)
+
+	if span == 0 {
+		return
+	}
+
+	h := opts.InitialStep
+	if h <= 0 {
+		h = initialStepSize(x, f, opts, tableau.q+1)
+	}
+
+	t := t0
+	stages := len(tableau.c)
+	k := make([]matrix.Matrix, stages)
+
+	for attempts := 0; ; attempts++ {
+		if attempts >= opts.MaxSteps {
+			return sol, IVPError{opts.Method, TooManySteps, t, h}
+		}
+
+		if opts.MaxStep > 0 {
+			h = math.Min(h, opts.MaxStep)
+		}
+
+		remaining := math.Abs(t1 - t)
+		last := h >= remaining
+		if last {
+			h = remaining
+		}
+
+		if h < opts.MinStep && !last {
+			return sol, IVPError{opts.Method, StepSizeTooSmall, t, h}
+		}
+
+		dt := direction * h
+
+		k[0] = f
+		for s := 1; s < stages; s++ {
+			xs := x.Copy()
+			for j, a := range tableau.a[s] {
+				if a != 0 {
+					xs.Add(matrix.Scaled(k[j], dt*a))
+				}
+			}
+
+			k[s], err = sol.evaluate(ode, xs, t+tableau.c[s]*dt, beta)
+			if err != nil {
+				return
+			}
+		}
+
+		xnew := x.Copy()
+		e := matrix.Zeros(ode.P, 1)
+		for s := 0; s < stages; s++ {
+			if tableau.b[s] != 0 {
+				xnew.Add(matrix.Scaled(k[s], dt*tableau.b[s]))
+			}
+			if tableau.e[s] != 0 {
+				e.Add(matrix.Scaled(k[s], dt*tableau.e[s]))
+			}
+		}
+
+		errorNorm := scaledNorm(e, x, xnew, opts)
+		if math.IsNaN(errorNorm) || math.IsInf(errorNorm, 0) || !isFinite(xnew) {
+			sol.Rejected++
+			h = h * 0.2
+			if h < opts.MinStep {
+				return sol, IVPError{opts.Method, NonFinite, t, h}
+			}
+			continue
+		}
+		factor := 0.9 * math.Pow(errorNorm, -1/float64(tableau.q+1))
+
+		if errorNorm > 1 {
+			sol.Rejected++
+			h = h * math.Max(0.2, factor)
+			continue
+		}
+
+		if tableau.d != nil {
+			sol.dense = append(sol.dense, tableau.continuousExtension(x, xnew, k, t, dt))
+		}
+
+		if last {
+			t = t1
+		} else {
+			t = t + dt
+		}
+
+		x = xnew
+		f = k[stages-1] // first same as last
+
+		if stop, err := sol.accept(ode, t, x, f, beta, events); err != nil || stop {
+			return sol, err
+		}
+
+		if last {
+			return
+		}
+
+		h = h * math.Min(5, math.Max(0.2, factor))
+	}
+}
+
+// Returns the continuous extension of a step of size dt from x at t to xnew,
+// with stages k whose last is F at xnew
+func (tableau rungeKuttaTableau) continuousExtension(x, xnew *matrix.DenseMatrix, k []matrix.Matrix, t, dt float64) *continuousExtension {
+	last := len(k) - 1
+
+	diff := matrix.Difference(xnew, x)
+	r2 := matrix.Difference(matrix.Scaled(k[0], dt), diff)
+	r3 := matrix.Difference(matrix.Difference(diff, matrix.Scaled(k[last], dt)), r2)
+
+	r4 := matrix.Zeros(x.Rows(), 1)
+	for s, d := range tableau.d {
+		if d != 0 {
+			r4.Add(matrix.Scaled(k[s], dt*d))
+		}
+	}
+
+	return &continuousExtension{t, dt, [5]*matrix.DenseMatrix{matrix.MakeDenseCopy(x), diff, r2, r3, r4}}
+}
+
+// Evaluates ode.F for an integrator and counts the evaluation. Failures are
+// reported at the last accepted step.
+func (sol *IVPSolution) evaluate(ode *ODE, x matrix.MatrixRO, t float64, beta matrix.MatrixRO) (matrix.Matrix, error) {
+	f, err := ode.F(x, t, beta)
+	if err != nil {
+		return nil, evaluationError("F", sol.lastStep(), t, err)
+	}
+	sol.Evaluations++
+	return f, nil
+}
+
+// Evaluates ode.Dfdx for an integrator and counts the evaluation
+func (sol *IVPSolution) jacobian(ode *ODE, x matrix.MatrixRO, t float64, beta matrix.MatrixRO) (matrix.Matrix, error) {
+	J, err := ode.Dfdx(x, t, beta)
+	if err != nil {
+		return nil, evaluationError("Dfdx", sol.lastStep(), t, err)
+	}
+	sol.Jacobians++
+	return J, nil
+}
+
+// Returns the index of the last accepted step, 0 before the first
+func (sol *IVPSolution) lastStep() int {
+	if len(sol.T) == 0 {
+		return 0
+	}
+	return len(sol.T) - 1
+}
+
+// Returns the root mean square of e scaled componentwise by the tolerances
+func scaledNorm(e, x, xnew matrix.MatrixRO, opts IVPOptions) float64 {
+	var ss float64
+	for j := 0; j < e.Rows(); j++ {
+		scale := opts.AbsTol + opts.RelTol*math.Max(math.Abs(x.Get(j, 0)), math.Abs(xnew.Get(j, 0)))
+		ss += math.Pow(e.Get(j, 0)/scale, 2)
+	}
+	return math.Sqrt(ss / float64(e.Rows()))
+}
+
+// Chooses a first step size from the scaled sizes of x and f, for a method
+// with local error of the given order
+func initialStepSize(x, f matrix.MatrixRO, opts IVPOptions, order int) float64 {
+	d0 := scaledNorm(x, x, x, opts)
+	d1 := scaledNorm(f, x, x, opts)
+
+	h := 1e-6
+	if d0 > 1e-5 && d1 > 1e-5 {
+		h = 0.01 * d0 / d1
+	}
+
+	// keep the first step's error estimate well within tolerance
+	if d1 > 0 {
+		h = math.Min(h, math.Pow(0.01/d1, 1/float64(order)))
+	}
+	return h
+}
+
+// Solves the initial value problem x(T[0]) = initialGuess with Integrate and
+// sets X to the dense output on T. If a terminal event stops the integration
+// early, X is held at the state of the event beyond it.
+func (bvp *BVP) SolveIVPWithOptions(initialGuess matrix.Matrix, opts IVPOptions) (sol IVPSolution, err error) {
+	sol, err = Integrate(&bvp.ODE, initialGuess, bvp.T[0], bvp.T[bvp.N-1], bvp.Beta, opts)
+	if err != nil {
+		return
+	}
+
+	bvp.X = sol.Sample(bvp.T)
+
+	if sol.Terminated {
+		end := sol.T[len(sol.T)-1]
+		for i := range bvp.T {
+			if bvp.T[i] > end {
+				bvp.X[i] = matrix.MakeDenseCopy(sol.X[len(sol.X)-1])
+			}
+		}
+	}
+	return
+}
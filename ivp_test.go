@@ -0,0 +1,214 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+// x' = x
+var exponentialODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.MakeDenseCopy(x)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		return matrix.Eye(1)
+	},
+	1, 0,
+)
+
+func TestIntegrateExponential(t *testing.T) {
+	for _, method := range []IVPMethod{DormandPrince54, BogackiShampine32} {
+		opts := DefaultIVPOptions()
+		opts.Method = method
+		opts.AbsTol, opts.RelTol = 1e-10, 1e-10
+
+		sol, err := Integrate(&exponentialODE, matrix.Ones(1, 1), 0, 2, matrix.Zeros(0, 1), opts)
+		if err != nil {
+			t.Errorf("%s: error integrating: %s", method, err)
+			continue
+		}
+
+		if sol.T[len(sol.T)-1] != 2 || len(sol.X) != sol.Steps+1 {
+			t.Errorf("%s: integration did not end at t = 2", method)
+		}
+
+		for i := range sol.T {
+			if e := math.Abs(sol.X[i].Get(0, 0)-math.Exp(sol.T[i])) / math.Exp(sol.T[i]); e > 1e-8 {
+				t.Errorf("%s: relative error %g at t = %f", method, e, sol.T[i])
+				break
+			}
+		}
+
+		// dense output between the steps
+		for _, tt := range []float64{0.123, 1, 1.777} {
+			if e := math.Abs(sol.At(tt).Get(0, 0)-math.Exp(tt)) / math.Exp(tt); e > 1e-6 {
+				t.Errorf("%s: dense output error %g at t = %f", method, e, tt)
+			}
+		}
+	}
+}
+
+func TestIntegrateBackwards(t *testing.T) {
+	sol, err := Integrate(&oscillatorODE, matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), 0, -3, matrix.Zeros(1, 1), DefaultIVPOptions())
+	if err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	for _, tt := range []float64{-0.5, -1.5, -3} {
+		x := sol.At(tt)
+		if e := math.Abs(x.Get(0, 0) - math.Sin(tt)); e > 1e-5 {
+			t.Errorf("Error %g at t = %f", e, tt)
+		}
+	}
+}
+
+func TestIntegrateMethodEfficiency(t *testing.T) {
+	// at tight tolerances the higher order method should take fewer steps
+	opts := DefaultIVPOptions()
+	opts.AbsTol, opts.RelTol = 1e-10, 1e-10
+
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+	x0 := matrix.MakeDenseMatrix([]float64{1, 1, 30}, 3, 1)
+
+	dp, err := Integrate(&LorenzODE, x0, 0, 1, beta, opts)
+	if err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	opts.Method = BogackiShampine32
+	bs, err := Integrate(&LorenzODE, x0, 0, 1, beta, opts)
+	if err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	if dp.Steps >= bs.Steps {
+		t.Errorf("Expected fewer Dormand-Prince steps, got %d and %d", dp.Steps, bs.Steps)
+	}
+
+	if d := matrix.Difference(dp.X[len(dp.X)-1], bs.X[len(bs.X)-1]).TwoNorm(); d > 1e-6 {
+		t.Errorf("Methods disagree by %g at t = 1", d)
+	}
+}
+
+func TestIntegrateLimits(t *testing.T) {
+	opts := DefaultIVPOptions()
+	opts.MaxSteps = 5
+
+	if _, err := Integrate(&exponentialODE, matrix.Ones(1, 1), 0, 10, matrix.Zeros(0, 1), opts); err == nil {
+		t.Errorf("Expected an error with too few steps")
+	}
+
+	if _, err := Integrate(&exponentialODE, matrix.Ones(2, 1), 0, 1, matrix.Zeros(0, 1), DefaultIVPOptions()); err == nil {
+		t.Errorf("Expected a dimension error")
+	}
+}
+
+func TestSolveIVPWithOptions(t *testing.T) {
+	bvp, err := newOscillatorMultipointBVP(11, []int{0, 10})
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	sol, err := bvp.SolveIVPWithOptions(matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), DefaultIVPOptions())
+	if err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	if len(bvp.X) != bvp.N || sol.Steps == 0 {
+		t.Errorf("Expected X to be sampled on the mesh")
+	}
+
+	if e := oscillatorMaxError(&bvp); e > 1e-5 {
+		t.Errorf("Maximum error %g", e)
+	}
+}
+
+func TestIntegrateZeroSpan(t *testing.T) {
+	for _, method := range []IVPMethod{DormandPrince54, BogackiShampine32, BDF, Rosenbrock23} {
+		opts := DefaultIVPOptions()
+		opts.Method = method
+
+		x0 := matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1)
+		sol, err := Integrate(&oscillatorODE, x0, 1, 1, matrix.Zeros(1, 1), opts)
+		if err != nil {
+			t.Errorf("%s: error integrating: %s", method, err)
+			continue
+		}
+
+		for _, x := range append(sol.Sample([]float64{1, 1}), sol.At(1)) {
+			if !matrix.Equals(x, x0) {
+				t.Errorf("%s: expected the initial value over a zero span, got %v", method, x)
+			}
+		}
+	}
+}
+
+func TestIntegrateNonFinite(t *testing.T) {
+	// x' = x, undefined beyond t = 0.5
+	ode := NewODE(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			if t > 0.5 {
+				return matrix.MakeDenseMatrix([]float64{math.NaN()}, 1, 1)
+			}
+			return matrix.MakeDenseCopy(x)
+		},
+		nil, 1, 0,
+	)
+
+	for _, method := range []IVPMethod{DormandPrince54, BogackiShampine32} {
+		opts := DefaultIVPOptions()
+		opts.Method = method
+
+		sol, err := Integrate(&ode, matrix.Ones(1, 1), 0, 1, matrix.Zeros(0, 1), opts)
+
+		ivpErr, ok := err.(IVPError)
+		if !ok || ivpErr.Reason != NonFinite || math.Abs(ivpErr.T-0.5) > 1e-6 {
+			t.Errorf("%s: expected a non-finite failure at t = 0.5, got %v", method, err)
+			continue
+		}
+
+		for i := range sol.X {
+			if !isFinite(sol.X[i]) {
+				t.Errorf("%s: accepted a non-finite step at t = %f", method, sol.T[i])
+				break
+			}
+		}
+	}
+}
+
+func TestDormandPrinceDenseOutput(t *testing.T) {
+	for _, t1 := range []float64{10, -10} {
+		sol, err := Integrate(&oscillatorODE, matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), 0, t1, matrix.Zeros(1, 1), DefaultIVPOptions())
+		if err != nil {
+			t.Errorf("Error integrating: %s", err)
+			return
+		}
+
+		// compare with the exact solution through X[i], so that only the
+		// error of the step and of the interpolation is measured
+		var dense, cubic float64
+		for i := 0; i+1 < len(sol.T); i++ {
+			local := func(tt float64) float64 {
+				dt := tt - sol.T[i]
+				return sol.X[i].Get(0, 0)*math.Cos(dt) + sol.X[i].Get(1, 0)*math.Sin(dt)
+			}
+
+			for _, s := range []float64{0.25, 0.5, 0.75} {
+				tt := sol.T[i] + s*(sol.T[i+1]-sol.T[i])
+				dense = math.Max(dense, math.Abs(sol.At(tt).Get(0, 0)-local(tt)))
+
+				x := hermite(sol.X[i], sol.F[i], sol.X[i+1], sol.F[i+1], sol.T[i], sol.T[i+1], tt)
+				cubic = math.Max(cubic, math.Abs(x.Get(0, 0)-local(tt)))
+			}
+		}
+		if dense > 1e-6 || cubic < 10*dense {
+			t.Errorf("Dense output error %g against %g for cubic Hermite", dense, cubic)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// Comparison of a Jacobian against a central difference approximation
+type JacobianCheck struct {
+	// per-entry discrepancies |J - Jfd| and |J - Jfd| / max(|J|, |Jfd|)
+	Abs, Rel       *matrix.DenseMatrix
+	MaxAbs, MaxRel float64
+
+	Pass bool // every entry agrees to within tolerance, absolutely or relatively
+}
+
+// Summary of JacobianChecks at several points
+type JacobianReport struct {
+	Checks         []JacobianCheck
+	MaxAbs, MaxRel float64
+	Pass           bool
+	Failures       []int // indices into Checks that did not pass
+}
+
+// Compares ODE.Dfdx at (x, t, beta) against central differences of ODE.F. It
+// is an error if the ODE has no analytic dfdx, since Dfdx is then itself a
+// finite difference approximation.
+func CheckJacobian(ode *ODE, x matrix.MatrixRO, t float64, beta matrix.MatrixRO, tolerance float64) (check JacobianCheck, err error) {
+	if ode.dfdx == nil {
+		return check, MatrixError("ODE has no analytic dfdx to check")
+	}
+
+	dfdx, err := ode.Dfdx(x, t, beta)
+	if err != nil {
+		return
+	}
+
+	reference, err := centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		return ode.F(v, t, beta)
+	}, x)
+	if err != nil {
+		return
+	}
+
+	return compareJacobians(dfdx, reference, tolerance), nil
+}
+
+// Runs CheckJacobian at every mesh point of a BVP
+func CheckJacobianMesh(bvp *BVP, tolerance float64) (report JacobianReport, err error) {
+	for i := 0; i < bvp.N; i++ {
+		check, err := CheckJacobian(&bvp.ODE, bvp.X[i], bvp.T[i], bvp.Beta, tolerance)
+		if err != nil {
+			return report, err
+		}
+		report.add(check)
+	}
+	return
+}
+
+// Comparison of the blocks from ConstraintMatrixBlocks and the boundary
+// Jacobians against central differences of ConstraintVectorBlocks. Ba and Bb
+// are not checked for linear multipoint boundary conditions.
+type ConstraintJacobianCheck struct {
+	A, B   JacobianReport // one check per mesh interval
+	Ba, Bb JacobianCheck  // boundary Jacobians with respect to x(t_1) and x(t_n)
+	Pass   bool
+}
+
+// Compares ConstraintMatrixBlocks against central differences of
+// ConstraintVectorBlocks. Only the blocks of ConstraintVectorBlocks which
+// depend on each X[i] are differenced.
+func CheckConstraintJacobian(bvp *BVP, tolerance float64) (check ConstraintJacobianCheck, err error) {
+	A, B, err := ConstraintMatrixBlocks(bvp)
+	if err != nil {
+		return
+	}
+
+	disc := bvp.discretization()
+
+	for i := 0; i < bvp.N-1; i++ {
+		xi, xj, ti, tj := bvp.X[i], bvp.X[i+1], bvp.T[i], bvp.T[i+1]
+
+		drdxi, err := centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+			return disc.Residual(&bvp.ODE, v, xj, ti, tj, bvp.Beta)
+		}, xi)
+		if err != nil {
+			return check, err
+		}
+
+		drdxj, err := centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+			return disc.Residual(&bvp.ODE, xi, v, ti, tj, bvp.Beta)
+		}, xj)
+		if err != nil {
+			return check, err
+		}
+
+		check.A.add(compareJacobians(A[i], drdxi, tolerance))
+		check.B.add(compareJacobians(B[i], drdxj, tolerance))
+	}
+
+	if bvp.Multipoint != nil {
+		check.Pass = check.A.Pass && check.B.Pass
+		return
+	}
+
+	bc := bvp.boundaryCondition()
+	x1, xn := bvp.X[0], bvp.X[bvp.N-1]
+
+	Ba, Bb, err := bvp.boundaryJacobian()
+	if err != nil {
+		return
+	}
+
+	dgdx1, err := centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		return bc.Residual(v, xn, bvp.Beta)
+	}, x1)
+	if err != nil {
+		return
+	}
+
+	dgdxn, err := centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+		return bc.Residual(x1, v, bvp.Beta)
+	}, xn)
+	if err != nil {
+		return
+	}
+
+	check.Ba = compareJacobians(Ba, dgdx1, tolerance)
+	check.Bb = compareJacobians(Bb, dgdxn, tolerance)
+
+	check.Pass = check.A.Pass && check.B.Pass && check.Ba.Pass && check.Bb.Pass
+	return
+}
+
+func (report *JacobianReport) add(check JacobianCheck) {
+	if len(report.Checks) == 0 {
+		report.Pass = true
+	}
+
+	if !check.Pass {
+		report.Pass = false
+		report.Failures = append(report.Failures, len(report.Checks))
+	}
+
+	report.Checks = append(report.Checks, check)
+	report.MaxAbs = math.Max(report.MaxAbs, check.MaxAbs)
+	report.MaxRel = math.Max(report.MaxRel, check.MaxRel)
+}
+
+// Approximates the Jacobian of g at v by central differences, with steps
+// scaled to the size of each component of v
+func centralDifference(g func(matrix.MatrixRO) (matrix.Matrix, error), v matrix.MatrixRO) (J *matrix.DenseMatrix, err error) {
+	step := math.Cbrt(machineEpsilon)
+
+	for j := 0; j < v.Rows(); j++ {
+		h := step * math.Max(math.Abs(v.Get(j, 0)), 1)
+
+		plus := matrix.MakeDenseCopy(v)
+		plus.Set(j, 0, v.Get(j, 0)+h)
+		gplus, err := g(plus)
+		if err != nil {
+			return nil, err
+		}
+
+		minus := matrix.MakeDenseCopy(v)
+		minus.Set(j, 0, v.Get(j, 0)-h)
+		gminus, err := g(minus)
+		if err != nil {
+			return nil, err
+		}
+
+		if J == nil {
+			J = matrix.Zeros(gplus.Rows(), v.Rows())
+		}
+		J.SetMatrix(0, j, matrix.Scaled(matrix.Difference(gplus, gminus), 1/(2*h)))
+	}
+	return
+}
+
+func compareJacobians(J, reference matrix.MatrixRO, tolerance float64) (check JacobianCheck) {
+	check.Abs = matrix.Zeros(J.Rows(), J.Cols())
+	check.Rel = matrix.Zeros(J.Rows(), J.Cols())
+	check.Pass = true
+
+	for i := 0; i < J.Rows(); i++ {
+		for j := 0; j < J.Cols(); j++ {
+			abs := math.Abs(J.Get(i, j) - reference.Get(i, j))
+			scale := math.Max(math.Abs(J.Get(i, j)), math.Abs(reference.Get(i, j)))
+
+			var rel float64
+			if scale > 0 {
+				rel = abs / scale
+			}
+
+			check.Abs.Set(i, j, abs)
+			check.Rel.Set(i, j, rel)
+			check.MaxAbs = math.Max(check.MaxAbs, abs)
+			check.MaxRel = math.Max(check.MaxRel, rel)
+
+			if abs > tolerance && rel > tolerance {
+				check.Pass = false
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"testing"
+)
+
+func TestCheckJacobianLorenz(t *testing.T) {
+	x := matrix.MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+
+	check, err := CheckJacobian(&LorenzODE, x, 0, beta, 1e-6)
+
+	if err != nil {
+		t.Errorf("Error checking Jacobian: %s", err)
+	}
+
+	if !check.Pass {
+		t.Errorf("LorenzDfdx disagrees with differences of LorenzF, max abs discrepancy %g", check.MaxAbs)
+	}
+}
+
+func TestCheckJacobianDetectsError(t *testing.T) {
+	wrongDfdx := func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		J := matrix.MakeDenseCopy(LorenzDfdx(x, t, beta))
+		J.Set(2, 0, -J.Get(2, 0))
+		return J
+	}
+
+	x := matrix.MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+	ode := NewODE(LorenzF, wrongDfdx, 3, 3)
+
+	check, err := CheckJacobian(&ode, x, 0, beta, 1e-6)
+
+	if err != nil {
+		t.Errorf("Error checking Jacobian: %s", err)
+	}
+
+	if check.Pass {
+		t.Errorf("Sign error in dfdx not detected")
+	}
+
+	if check.Abs.Get(2, 0) < 1 || check.Abs.Get(0, 0) > 1e-6 {
+		t.Errorf("Discrepancy reported for the wrong entries")
+	}
+}
+
+func TestCheckJacobianMesh(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(11)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	report, err := CheckJacobianMesh(&MattheijBVP, 1e-6)
+
+	if err != nil {
+		t.Errorf("Error checking Jacobian: %s", err)
+	}
+
+	if !report.Pass || len(report.Checks) != 11 || len(report.Failures) != 0 {
+		t.Errorf("Mesh Jacobian check failed, max abs discrepancy %g", report.MaxAbs)
+	}
+}
+
+func TestCheckConstraintJacobian(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(11)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	for _, disc := range []Discretization{Trapezoidal{}, HermiteSimpson{}, GaussLegendre6} {
+		MattheijBVP.Discretization = disc
+
+		check, err := CheckConstraintJacobian(&MattheijBVP, 1e-6)
+
+		if err != nil {
+			t.Errorf("Error checking constraint Jacobian: %s", err)
+		}
+
+		if !check.Pass || len(check.A.Checks) != 10 || len(check.B.Checks) != 10 {
+			t.Errorf("Order %d constraint Jacobian check failed", disc.Order())
+		}
+	}
+}
+
+func TestCheckJacobianWithoutDfdx(t *testing.T) {
+	x := matrix.MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+	ode := NewODE(LorenzF, nil, 3, 3)
+
+	if _, err := CheckJacobian(&ode, x, 0, beta, 1e-6); err == nil {
+		t.Errorf("Expected an error checking finite differences against themselves")
+	}
+}
@@ -5,7 +5,7 @@ import (
 )
 
 var (
-	LorenzODE = NewODE(LorenzF, LorenzDfdx, 3, 3)
+	LorenzODE = NewODEWithParamJacobian(LorenzF, LorenzDfdx, LorenzDfdbeta, 3, 3)
 )
 
 func LorenzF(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
@@ -20,6 +20,14 @@ func LorenzDfdx(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matri
 	return matrix.MakeDenseMatrix([]float64{
 		-beta.Get(0, 0), beta.Get(0, 0), 0,
 		beta.Get(1, 0) - x.Get(2, 0), -1, -x.Get(0, 0),
-		x.Get(0, 0), x.Get(0, 0), -beta.Get(2, 0),
+		x.Get(1, 0), x.Get(0, 0), -beta.Get(2, 0),
+	}, 3, 3)
+}
+
+func LorenzDfdbeta(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+	return matrix.MakeDenseMatrix([]float64{
+		x.Get(1, 0) - x.Get(0, 0), 0, 0,
+		0, x.Get(0, 0), 0,
+		0, 0, -x.Get(2, 0),
 	}, 3, 3)
 }
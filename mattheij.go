@@ -25,6 +25,27 @@ func MattheijQ(t float64, beta matrix.MatrixRO) matrix.Matrix {
 	return q
 }
 
-var MattheijF, MattheijDfdx = LinearFDfdx(MattheijA, MattheijQ)
+func MattheijDadbeta(t float64, beta matrix.MatrixRO) []matrix.Matrix {
+	dadbeta0 := matrix.Zeros(3, 3)
+	dadbeta0.Set(0, 0, -math.Cos(beta.Get(1, 0)*t))
+	dadbeta0.Set(0, 2, math.Sin(beta.Get(1, 0)*t))
+	dadbeta0.Set(1, 1, 1)
+	dadbeta0.Set(2, 0, math.Sin(beta.Get(1, 0)*t))
+	dadbeta0.Set(2, 2, math.Cos(beta.Get(1, 0)*t))
 
-var MattheijODE = NewODE(MattheijF, MattheijDfdx, 3, 2)
+	dadbeta1 := matrix.Zeros(3, 3)
+	dadbeta1.Set(0, 0, beta.Get(0, 0)*t*math.Sin(beta.Get(1, 0)*t))
+	dadbeta1.Set(0, 2, beta.Get(0, 0)*t*math.Cos(beta.Get(1, 0)*t))
+	dadbeta1.Set(2, 0, beta.Get(0, 0)*t*math.Cos(beta.Get(1, 0)*t))
+	dadbeta1.Set(2, 2, -beta.Get(0, 0)*t*math.Sin(beta.Get(1, 0)*t))
+
+	return []matrix.Matrix{dadbeta0, dadbeta1}
+}
+
+func MattheijDqdbeta(t float64, beta matrix.MatrixRO) matrix.Matrix {
+	return matrix.Zeros(3, 2)
+}
+
+var MattheijF, MattheijDfdx, MattheijDfdbeta = LinearFDfdx(MattheijA, MattheijQ, MattheijDadbeta, MattheijDqdbeta)
+
+var MattheijODE = NewODEWithParamJacobian(MattheijF, MattheijDfdx, MattheijDfdbeta, 3, 2)
@@ -0,0 +1,228 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// Options for SolveAdaptive
+type AdaptiveOptions struct {
+	// Target for the estimated local error on every interval, measured as
+	// max_j |e_j| / (1 + |x_j|)
+	Tolerance float64
+
+	MaxRefinements int // maximum number of solve and remesh cycles
+	MaxPoints      int // upper limit on the number of mesh points
+
+	Solve SolveOptions // options for each solve on a fixed mesh
+}
+
+func DefaultAdaptiveOptions() AdaptiveOptions {
+	return AdaptiveOptions{
+		Tolerance:      1e-6,
+		MaxRefinements: 10,
+		MaxPoints:      20000,
+		Solve:          DefaultSolveOptions(),
+	}
+}
+
+// Report of a call to SolveAdaptive
+type AdaptiveResult struct {
+	Mesh        []float64 // final mesh, also stored in BVP.T
+	Errors      []float64 // estimated local error on each interval of Mesh
+	MaxError    float64
+	Refinements int         // number of times the mesh was changed
+	Solve       SolveResult // report from the solve on the final mesh
+}
+
+// Solves the BVP, then repeatedly estimates the local discretization error on
+// each mesh interval, equidistributes it by inserting and removing mesh points,
+// interpolates X onto the new mesh and re-solves until every interval meets
+// opts.Tolerance.
+func (bvp *BVP) SolveAdaptive(opts AdaptiveOptions) (result AdaptiveResult, err error) {
+	for {
+		result.Solve, err = bvp.SolveWithOptions(opts.Solve)
+		if err != nil {
+			return
+		}
+
+		result.Errors, err = intervalErrors(bvp)
+		if err != nil {
+			return
+		}
+
+		result.Mesh = bvp.T
+		result.MaxError = 0
+		for _, e := range result.Errors {
+			result.MaxError = math.Max(result.MaxError, e)
+		}
+
+		if result.MaxError <= opts.Tolerance {
+			return
+		}
+
+		if result.Refinements >= opts.MaxRefinements {
+			return result, NonConvergenceError{"Adaptive mesh refinement did not reach tolerance", result.Refinements, result.Solve.Cost, result.Solve.StepNorm}
+		}
+
+		var fixed []int
+		if bvp.Multipoint != nil {
+			fixed = bvp.Multipoint.Indices
+		}
+
+		mesh := refineMesh(bvp.T, result.Errors, opts.Tolerance, bvp.discretization().Order(), fixed)
+		if len(mesh) > opts.MaxPoints {
+			return result, NonConvergenceError{"Adaptive mesh refinement exceeded the maximum number of mesh points", result.Refinements, result.Solve.Cost, result.Solve.StepNorm}
+		}
+
+		err = bvp.setMesh(mesh)
+		if err != nil {
+			return
+		}
+		result.Refinements++
+	}
+}
+
+// Interpolates X onto a new mesh and replaces T. The new mesh must keep the
+// points used by multipoint boundary conditions.
+func (bvp *BVP) setMesh(mesh []float64) (err error) {
+	x, err := interpolateMesh(&bvp.ODE, bvp.X, bvp.T, bvp.Beta, mesh)
+	if err != nil {
+		return
+	}
+
+	if bvp.Multipoint != nil {
+		bvp.Multipoint, err = bvp.Multipoint.remesh(bvp.T, mesh)
+		if err != nil {
+			return
+		}
+	}
+
+	bvp.X = x
+	bvp.T = mesh
+	bvp.N = len(mesh)
+	bvp.correction = nil
+	return
+}
+
+// Estimates the local error of the discretization on each mesh interval by
+// comparing one step from X[i] across the interval with two half steps
+func intervalErrors(bvp *BVP) (errors []float64, err error) {
+	disc := bvp.discretization()
+	factor := math.Pow(2, float64(disc.Order()))
+	factor = factor / (factor - 1)
+
+	errors = make([]float64, bvp.N-1)
+
+	for i := 0; i < bvp.N-1; i++ {
+		ti, tj := bvp.T[i], bvp.T[i+1]
+		tm := (ti + tj) / 2
+
+		full, err := discreteStep(disc, &bvp.ODE, i, bvp.X[i], ti, tj, bvp.Beta)
+		if err != nil {
+			return nil, err
+		}
+
+		half, err := discreteStep(disc, &bvp.ODE, i, bvp.X[i], ti, tm, bvp.Beta)
+		if err != nil {
+			return nil, err
+		}
+
+		half, err = discreteStep(disc, &bvp.ODE, i, half, tm, tj, bvp.Beta)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < bvp.ODE.P; j++ {
+			e := factor * math.Abs(full.Get(j, 0)-half.Get(j, 0)) / (1 + math.Abs(bvp.X[i+1].Get(j, 0)))
+			errors[i] = math.Max(errors[i], e)
+		}
+	}
+	return
+}
+
+// Advances xi from ti to tj within mesh interval index by solving the
+// discretization residual for xj with Newton iteration, starting from an
+// explicit Euler step
+func discreteStep(disc Discretization, ode *ODE, index int, xi matrix.MatrixRO, ti, tj float64, beta matrix.MatrixRO) (xj *matrix.DenseMatrix, err error) {
+	tolerance := 1e-13
+	maxiter := 50
+
+	f, err := ode.F(xi, ti, beta)
+	if err != nil {
+		return nil, evaluationError("F", index, ti, err)
+	}
+	xj = matrix.Sum(xi, matrix.Scaled(f, tj-ti))
+
+	var cost, stepNorm float64
+	for iter := 0; iter < maxiter; iter++ {
+		r, err := disc.Residual(ode, xi, xj, ti, tj, beta)
+		if err != nil {
+			return nil, evaluationError("F", index, ti, err)
+		}
+
+		_, B, err := disc.Jacobian(ode, xi, xj, ti, tj, beta)
+		if err != nil {
+			return nil, evaluationError("Dfdx", index, ti, err)
+		}
+
+		delta, err := B.Solve(r)
+		if err != nil {
+			return nil, err
+		}
+		if !isFinite(delta) {
+			return nil, SingularSystemError{index + 1}
+		}
+		xj.Subtract(delta)
+		cost, stepNorm = sumOfSquares([]*matrix.DenseMatrix{r}), delta.TwoNorm()
+
+		if !exceedsTolerance([]*matrix.DenseMatrix{delta}, []matrix.Matrix{xj}, tolerance, tolerance) {
+			return xj, nil
+		}
+	}
+
+	return nil, NonConvergenceError{"Discrete step did not converge", maxiter, cost, stepNorm}
+}
+
+// Returns a new mesh which equidistributes the interval errors. Intervals
+// above tolerance are split into enough pieces to bring them below it, and
+// pairs of intervals whose merged error would stay well below tolerance are
+// joined by removing the point between them. The points T[j] for j in fixed
+// are never removed.
+func refineMesh(T []float64, errors []float64, tolerance float64, order int, fixed []int) (mesh []float64) {
+	// the local error of a one-step method of order p scales as h^(p+1)
+	exponent := 1 / float64(order+1)
+	mergeFactor := math.Pow(2, float64(order+1))
+
+	keep := make([]bool, len(T))
+	for _, j := range fixed {
+		keep[j] = true
+	}
+
+	mesh = append(mesh, T[0])
+	for i := 0; i < len(errors); i++ {
+		if i+1 < len(errors) && !keep[i+1] && (errors[i]+errors[i+1])*mergeFactor < tolerance/4 {
+			// drop T[i+1]
+			mesh = append(mesh, T[i+2])
+			i++
+			continue
+		}
+
+		pieces := 1
+		if errors[i] > tolerance {
+			pieces = int(math.Ceil(1.2 * math.Pow(errors[i]/tolerance, exponent)))
+			if pieces < 2 {
+				pieces = 2
+			}
+			if pieces > 8 {
+				pieces = 8
+			}
+		}
+
+		for k := 1; k < pieces; k++ {
+			mesh = append(mesh, T[i]+(T[i+1]-T[i])*float64(k)/float64(pieces))
+		}
+		mesh = append(mesh, T[i+1])
+	}
+	return
+}
@@ -0,0 +1,103 @@
+package bvp
+
+import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestSolveAdaptiveMattheij(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(6)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	for i := range MattheijBVP.X {
+		MattheijBVP.X[i] = matrix.Zeros(3, 1)
+	}
+
+	opts := DefaultAdaptiveOptions()
+	opts.Tolerance = 1e-7
+	opts.Solve.AbsTol = 1e-10
+
+	result, err := (&MattheijBVP).SolveAdaptive(opts)
+
+	if err != nil {
+		t.Errorf("Error solving: %s", err)
+	}
+
+	if result.MaxError > opts.Tolerance {
+		t.Errorf("Estimated error above tolerance, got %g", result.MaxError)
+	}
+
+	if result.Refinements == 0 || len(result.Mesh) <= 6 {
+		t.Errorf("Expected the mesh to be refined")
+	}
+
+	if len(result.Errors) != len(result.Mesh)-1 || MattheijBVP.N != len(result.Mesh) || len(MattheijBVP.X) != MattheijBVP.N {
+		t.Errorf("Inconsistent mesh sizes after refinement")
+	}
+
+	if e := mattheijMaxError(&MattheijBVP); e > 1e-4 {
+		t.Errorf("Error against exact solution too large, got %g", e)
+	}
+}
+
+func TestRefineMesh(t *testing.T) {
+	T := []float64{0, 1, 2, 3, 4}
+	errors := []float64{1e-12, 1e-12, 1e-5, 1e-8}
+
+	mesh := refineMesh(T, errors, 1e-6, 2, nil)
+
+	expected := []float64{0, 2, 2 + 1./3., 2 + 2./3., 3, 4}
+	if len(mesh) != len(expected) {
+		t.Errorf("Incorrect refined mesh, expected %v, got %v", expected, mesh)
+		return
+	}
+
+	for i := range mesh {
+		if math.Abs(mesh[i]-expected[i]) > 1e-12 {
+			t.Errorf("Incorrect refined mesh, expected %v, got %v", expected, mesh)
+		}
+	}
+}
+
+func TestRefineMeshKeepsFixedPoints(t *testing.T) {
+	T := []float64{0, 1, 2, 3, 4}
+	errors := []float64{1e-12, 1e-12, 1e-12, 1e-12}
+
+	mesh := refineMesh(T, errors, 1e-6, 2, []int{1})
+
+	expected := []float64{0, 1, 3, 4}
+	if len(mesh) != len(expected) {
+		t.Errorf("Incorrect refined mesh, expected %v, got %v", expected, mesh)
+		return
+	}
+
+	for i := range mesh {
+		if math.Abs(mesh[i]-expected[i]) > 1e-12 {
+			t.Errorf("Incorrect refined mesh, expected %v, got %v", expected, mesh)
+		}
+	}
+}
+
+func TestSolveAdaptiveNonConvergence(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(6)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	opts := DefaultAdaptiveOptions()
+	opts.Tolerance = 1e-7
+	opts.MaxRefinements = 0
+
+	result, err := (&MattheijBVP).SolveAdaptive(opts)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) || convErr.Iterations != 0 || convErr.Cost != result.Solve.Cost {
+		t.Errorf("Expected a NonConvergenceError with no refinements, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"sort"
+)
+
+// Linear multipoint boundary conditions sum_k B[k] x(t_Indices[k]) = Rhs,
+// coupling any mesh points rather than only x(t_1) and x(t_n)
+type MultipointBoundaryCondition struct {
+	Indices []int // mesh indices j_k
+	B       []matrix.Matrix
+	Rhs     matrix.Matrix
+}
+
+// Creates a BVP with multipoint boundary conditions. B0, B1, B and BC are
+// left nil.
+func NewMultipointBVP(ode ODE, initialGuess []matrix.Matrix, timeMesh []float64, bc MultipointBoundaryCondition, beta matrix.Matrix) (BVP, error) {
+	var bvp BVP
+
+	n := len(timeMesh)
+
+	if err := checkInitialGuess("NewMultipointBVP", ode, initialGuess, n); err != nil {
+		return bvp, err
+	}
+
+	if beta.Rows() != ode.Q || beta.Cols() != 1 {
+		return bvp, NewDimensionErrorIn("NewMultipointBVP", "beta", ode.Q, 1, beta.Rows(), beta.Cols())
+	}
+
+	if len(bc.Indices) == 0 || len(bc.B) != len(bc.Indices) {
+		return bvp, NewDimensionErrorIn("NewMultipointBVP", "B", ode.P, ode.P*len(bc.Indices), ode.P, ode.P*len(bc.B))
+	}
+
+	for k, j := range bc.Indices {
+		if j < 0 || j >= n {
+			return bvp, MatrixError("Multipoint boundary condition refers to a mesh index which does not exist")
+		}
+
+		if bc.B[k].Rows() != ode.P || bc.B[k].Cols() != ode.P {
+			return bvp, NewDimensionErrorIn("NewMultipointBVP", "B", ode.P, ode.P, bc.B[k].Rows(), bc.B[k].Cols())
+		}
+	}
+
+	if bc.Rhs.Rows() != ode.P || bc.Rhs.Cols() != 1 {
+		return bvp, NewDimensionErrorIn("NewMultipointBVP", "Rhs", ode.P, 1, bc.Rhs.Rows(), bc.Rhs.Cols())
+	}
+
+	indices := make([]int, len(bc.Indices))
+	copy(indices, bc.Indices)
+
+	bvp = BVP{ODE: ode, X: initialGuess, T: timeMesh, Beta: beta, N: n, Multipoint: &MultipointBoundaryCondition{indices, bc.B, bc.Rhs}}
+	return bvp, nil
+}
+
+// Evaluates sum_k B[k] X[Indices[k]] - Rhs
+func (bc *MultipointBoundaryCondition) residual(X []matrix.Matrix) *matrix.DenseMatrix {
+	g := matrix.Scaled(bc.Rhs, -1)
+	for k, j := range bc.Indices {
+		g.Add(matrix.Product(bc.B[k], X[j]))
+	}
+	return g
+}
+
+// Maps Indices from the mesh T onto mesh, which must contain every
+// referenced time of T
+func (bc *MultipointBoundaryCondition) remesh(T, mesh []float64) (*MultipointBoundaryCondition, error) {
+	indices := make([]int, len(bc.Indices))
+	for k, j := range bc.Indices {
+		indices[k] = sort.SearchFloat64s(mesh, T[j])
+		if indices[k] == len(mesh) || mesh[indices[k]] != T[j] {
+			return nil, MatrixError("New mesh does not contain the points of the multipoint boundary conditions")
+		}
+	}
+	return &MultipointBoundaryCondition{indices, bc.B, bc.Rhs}, nil
+}
+
+// Returns the rows Ga, Gb of the end system for multipoint conditions. Back
+// substitution gives each interior delta_j = g_j + G_j delta_1 + H_j delta_n,
+// where g_j depends only on the right hand side, so the conditions become
+//
+//	Ga delta_1 + Gb delta_n = c - sum_k B[k] g_j_k
+func (f *factorisation) multipointEnds(bc *MultipointBoundaryCondition) (Ga, Gb *matrix.DenseMatrix) {
+	n, m := f.n, f.m
+
+	Ga = matrix.Zeros(m, m)
+	Gb = matrix.Zeros(m, m)
+
+	q := make([]*matrix.DenseMatrix, n)
+	for i := range q {
+		q[i] = matrix.Zeros(m, 1)
+	}
+
+	for end := 0; end < 2; end++ {
+		for l := 0; l < m; l++ {
+			xc := make([]*matrix.DenseMatrix, n)
+			for i := range xc {
+				xc[i] = matrix.Zeros(m, 1)
+			}
+			if end == 0 {
+				xc[0].Set(l, 0, 1)
+			} else {
+				xc[n-1].Set(l, 0, 1)
+			}
+
+			rightBackSubstitute(f.B, f.C, f.D, f.U, q, xc, n, m)
+
+			G := Ga
+			if end == 1 {
+				G = Gb
+			}
+			for k, j := range bc.Indices {
+				column := matrix.Product(bc.B[k], xc[j])
+				for r := 0; r < m; r++ {
+					G.Set(r, l, G.Get(r, l)+column.Get(r, 0))
+				}
+			}
+		}
+	}
+	return
+}
+
+// Returns sum_k B[k] g_j_k for the particular solution g of the back
+// substitution with delta_1 = delta_n = 0
+func (f *factorisation) multipointOffset(bc *MultipointBoundaryCondition, c []*matrix.DenseMatrix) *matrix.DenseMatrix {
+	n, m := f.n, f.m
+
+	xc := make([]*matrix.DenseMatrix, n)
+	for i := range xc {
+		xc[i] = matrix.Zeros(m, 1)
+	}
+
+	rightBackSubstitute(f.B, f.C, f.D, f.U, c, xc, n, m)
+
+	offset := matrix.Zeros(m, 1)
+	for k, j := range bc.Indices {
+		offset.Add(matrix.Product(bc.B[k], xc[j]))
+	}
+	return offset
+}
@@ -0,0 +1,110 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestMultipointBoundaryCondition(t *testing.T) {
+	n := 201
+	bvp, err := newOscillatorMultipointBVP(n, []int{0, n / 2})
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	err = bvp.Solve()
+
+	if err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+	}
+
+	if e := oscillatorMaxError(&bvp); e > 1e-4 {
+		t.Errorf("Error against exact solution too large, got %g", e)
+	}
+
+	g, _ := bvp.boundaryResidual()
+	if math.Sqrt(sumOfSquares([]*matrix.DenseMatrix{g})) > 1e-8 {
+		t.Errorf("Multipoint boundary conditions not satisfied")
+	}
+}
+
+func TestMultipointMatchesTwoPoint(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(21)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	bc := MultipointBoundaryCondition{[]int{0, 20}, []matrix.Matrix{MattheijBVP.B0, MattheijBVP.B1}, MattheijBVP.B}
+	initialGuess := make([]matrix.Matrix, 21)
+	for i := range initialGuess {
+		initialGuess[i] = matrix.Zeros(3, 1)
+		MattheijBVP.X[i] = matrix.Zeros(3, 1)
+	}
+
+	multipoint, err := NewMultipointBVP(MattheijODE, initialGuess, MattheijBVP.T, bc, MattheijBVP.Beta)
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	for _, bvp := range []*BVP{&MattheijBVP, &multipoint} {
+		if err = bvp.Solve(); err != nil {
+			t.Errorf("Error solving BVP: %s", err)
+		}
+	}
+
+	for i := range MattheijBVP.X {
+		if !matrix.ApproxEquals(MattheijBVP.X[i], multipoint.X[i], 1e-10) {
+			t.Errorf("Multipoint conditions at the ends give a different solution")
+			break
+		}
+	}
+}
+
+func TestChecksMultipointIndices(t *testing.T) {
+	_, err := newOscillatorMultipointBVP(11, []int{0, 5})
+
+	if err != nil {
+		t.Errorf("Incorrect mesh index check. Correct indices used.")
+	}
+
+	_, err = newOscillatorMultipointBVP(11, []int{0, 11})
+
+	if err == nil {
+		t.Errorf("Incorrect mesh index check. Index past the end used.")
+	}
+
+	_, err = newOscillatorMultipointBVP(11, []int{-1, 5})
+
+	if err == nil {
+		t.Errorf("Incorrect mesh index check. Negative index used.")
+	}
+}
+
+func TestMultipointAdaptive(t *testing.T) {
+	bvp, err := newOscillatorMultipointBVP(11, []int{0, 5})
+
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	_, err = bvp.SolveAdaptive(DefaultAdaptiveOptions())
+
+	if err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+	}
+
+	if j := bvp.Multipoint.Indices[1]; bvp.T[j] != 1 {
+		t.Errorf("Interior condition moved to t = %g", bvp.T[j])
+	}
+
+	if e := oscillatorMaxError(&bvp); e > 1e-4 {
+		t.Errorf("Error against exact solution too large, got %g", e)
+	}
+}
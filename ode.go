@@ -6,44 +6,159 @@ package bvp
 
 import (
 	"github.com/sbroadfoot90/go.matrix"
+	"math"
 )
 
 type ODE struct {
 	// dx/dt = F(x, t, beta)
 	f, dfdx func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix
 
+	// optional Jacobian of f with respect to beta
+	dfdbeta func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix
+
 	P, // number of varibales (length of x)
 	Q int // number of parameters (length of beta)
+
+	// scheme used to approximate dfdx or dfdbeta when they are nil
+	Differencing DifferenceScheme
 }
 
+type DifferenceScheme int
+
+const (
+	ForwardDifference DifferenceScheme = iota
+	CentralDifference
+)
+
+// machine epsilon for float64
+const machineEpsilon = 2.220446049250313e-16
+
+// dfdx may be nil, in which case Dfdx is computed by finite differences of f
 func NewODE(f, dfdx func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix, p, q int) ODE {
-	return ODE{f, dfdx, p, q}
+	return ODE{f, dfdx, nil, p, q, ForwardDifference}
+}
+
+// dfdx and dfdbeta may be nil, in which case they are computed by finite
+// differences of f
+func NewODEWithParamJacobian(f, dfdx, dfdbeta func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix, p, q int) ODE {
+	return ODE{f, dfdx, dfdbeta, p, q, ForwardDifference}
 }
 
 // Evaluates the function f with checking of matrix dimensions
 func (o *ODE) F(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) (matrix.Matrix, error) {
 	//checking dimensions of input
 	if x.Rows() != o.P || x.Cols() != 1 {
-		return nil, NewDimensionError("x", o.P, 1, x.Rows(), x.Cols())
+		return nil, NewDimensionErrorIn("ODE.F", "x", o.P, 1, x.Rows(), x.Cols())
 	}
 
 	if o.Q != 0 && (beta.Rows() != o.Q || beta.Cols() != 1) {
-		return nil, NewDimensionError("beta", o.Q, 1, beta.Rows(), beta.Cols())
+		return nil, NewDimensionErrorIn("ODE.F", "beta", o.Q, 1, beta.Rows(), beta.Cols())
 	}
 
-	return o.f(x, t, beta), nil
+	f := o.f(x, t, beta)
+	if f.Rows() != o.P || f.Cols() != 1 {
+		return nil, NewDimensionErrorIn("ODE.F", "f", o.P, 1, f.Rows(), f.Cols())
+	}
+
+	return f, nil
 }
 
-// Evaluates the function dfdx with checking of matrix dimensions
+// Evaluates the function dfdx with checking of matrix dimensions, or its
+// finite difference approximation if dfdx is nil
 func (o *ODE) Dfdx(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) (matrix.Matrix, error) {
 	//checking dimensions of input
 	if x.Rows() != o.P || x.Cols() != 1 {
-		return nil, NewDimensionError("x", o.P, 1, x.Rows(), x.Cols())
+		return nil, NewDimensionErrorIn("ODE.Dfdx", "x", o.P, 1, x.Rows(), x.Cols())
+	}
+
+	if o.Q != 0 && (beta.Rows() != o.Q || beta.Cols() != 1) {
+		return nil, NewDimensionErrorIn("ODE.Dfdx", "beta", o.Q, 1, beta.Rows(), beta.Cols())
+	}
+
+	if o.dfdx == nil {
+		return o.difference(x, t, beta, false), nil
+	}
+
+	dfdx := o.dfdx(x, t, beta)
+	if dfdx.Rows() != o.P || dfdx.Cols() != o.P {
+		return nil, NewDimensionErrorIn("ODE.Dfdx", "dfdx", o.P, o.P, dfdx.Rows(), dfdx.Cols())
+	}
+
+	return dfdx, nil
+}
+
+// Evaluates the function dfdbeta with checking of matrix dimensions, or its
+// finite difference approximation if dfdbeta is nil
+func (o *ODE) Dfdbeta(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) (matrix.Matrix, error) {
+	//checking dimensions of input
+	if x.Rows() != o.P || x.Cols() != 1 {
+		return nil, NewDimensionErrorIn("ODE.Dfdbeta", "x", o.P, 1, x.Rows(), x.Cols())
 	}
 
 	if o.Q != 0 && (beta.Rows() != o.Q || beta.Cols() != 1) {
-		return nil, NewDimensionError("beta", o.Q, 1, beta.Rows(), beta.Cols())
+		return nil, NewDimensionErrorIn("ODE.Dfdbeta", "beta", o.Q, 1, beta.Rows(), beta.Cols())
+	}
+
+	if o.dfdbeta == nil {
+		return o.difference(x, t, beta, true), nil
+	}
+
+	dfdbeta := o.dfdbeta(x, t, beta)
+	if dfdbeta.Rows() != o.P || dfdbeta.Cols() != o.Q {
+		return nil, NewDimensionErrorIn("ODE.Dfdbeta", "dfdbeta", o.P, o.Q, dfdbeta.Rows(), dfdbeta.Cols())
 	}
 
-	return o.dfdx(x, t, beta), nil
+	return dfdbeta, nil
+}
+
+// Approximates the Jacobian of f with respect to x, or to beta if
+// wrtBeta, by finite differences with per-component steps scaled to the
+// size of each component
+func (o *ODE) difference(x matrix.MatrixRO, t float64, beta matrix.MatrixRO, wrtBeta bool) matrix.Matrix {
+	v := x
+	if wrtBeta {
+		v = beta
+	}
+	n := v.Rows()
+
+	// steps balancing truncation and rounding error for each scheme
+	step := math.Sqrt(machineEpsilon)
+	if o.Differencing == CentralDifference {
+		step = math.Cbrt(machineEpsilon)
+	}
+
+	var fx matrix.Matrix
+	if o.Differencing == ForwardDifference {
+		fx = o.f(x, t, beta)
+	}
+
+	J := matrix.Zeros(o.P, n)
+	for j := 0; j < n; j++ {
+		h := step * math.Max(math.Abs(v.Get(j, 0)), 1)
+
+		plus := matrix.MakeDenseCopy(v)
+		plus.Set(j, 0, v.Get(j, 0)+h)
+		fplus := o.evaluate(x, t, beta, plus, wrtBeta)
+
+		var column *matrix.DenseMatrix
+		if o.Differencing == CentralDifference {
+			minus := matrix.MakeDenseCopy(v)
+			minus.Set(j, 0, v.Get(j, 0)-h)
+			fminus := o.evaluate(x, t, beta, minus, wrtBeta)
+			column = matrix.Scaled(matrix.Difference(fplus, fminus), 1/(2*h))
+		} else {
+			column = matrix.Scaled(matrix.Difference(fplus, fx), 1/h)
+		}
+
+		J.SetMatrix(0, j, column)
+	}
+	return J
+}
+
+// Evaluates f with v substituted for x, or for beta if wrtBeta
+func (o *ODE) evaluate(x matrix.MatrixRO, t float64, beta matrix.MatrixRO, v matrix.MatrixRO, wrtBeta bool) matrix.Matrix {
+	if wrtBeta {
+		return o.f(x, t, v)
+	}
+	return o.f(v, t, beta)
 }
@@ -82,3 +82,112 @@ func TestDfdxChecksBetaDim(t *testing.T) {
 		t.Errorf("Incorrect beta dim checking. Incorrect cols not detected.")
 	}
 }
+
+func TestDfdbetaChecksDims(t *testing.T) {
+	ODEdfdbeta, err := MattheijODE.Dfdbeta(matrix.Zeros(3, 1), 0, matrix.Zeros(2, 1))
+
+	if err != nil || ODEdfdbeta == nil {
+		t.Errorf("Incorrect dim checking. Correct dims used.")
+	}
+
+	if ODEdfdbeta.Rows() != 3 || ODEdfdbeta.Cols() != 2 {
+		t.Errorf("Incorrect dfdbeta dims, expected (3, 2), got (%d, %d)", ODEdfdbeta.Rows(), ODEdfdbeta.Cols())
+	}
+
+	ODEdfdbeta, err = MattheijODE.Dfdbeta(matrix.Zeros(4, 1), 0, matrix.Zeros(2, 1))
+	if err == nil {
+		t.Errorf("Incorrect x dim checking. Incorrect rows not detected.")
+	}
+
+	ODEdfdbeta, err = MattheijODE.Dfdbeta(matrix.Zeros(3, 1), 0, matrix.Zeros(2, 4))
+	if err == nil {
+		t.Errorf("Incorrect beta dim checking. Incorrect cols not detected.")
+	}
+}
+
+func TestDfdbetaDifference(t *testing.T) {
+	x := matrix.MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+
+	for _, scheme := range []DifferenceScheme{ForwardDifference, CentralDifference} {
+		ode := NewODE(LorenzF, LorenzDfdx, 3, 3)
+		ode.Differencing = scheme
+
+		ODEdfdbeta, err := ode.Dfdbeta(x, 0, beta)
+		if err != nil {
+			t.Errorf("Error calculating dfdbeta by differences: %s", err)
+			continue
+		}
+
+		if !matrix.ApproxEquals(ODEdfdbeta, LorenzDfdbeta(x, 0, beta), 1e-6) {
+			t.Errorf("Incorrect dfdbeta by differences for scheme %d", scheme)
+		}
+	}
+}
+
+func TestDfdxDifference(t *testing.T) {
+	x := matrix.MakeDenseMatrix([]float64{1, 0.1, 0.14}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{19, 2}, 2, 1)
+
+	for _, scheme := range []DifferenceScheme{ForwardDifference, CentralDifference} {
+		ode := NewODE(MattheijF, nil, 3, 2)
+		ode.Differencing = scheme
+
+		ODEdfdx, err := ode.Dfdx(x, 0.5, beta)
+		if err != nil {
+			t.Errorf("Error calculating dfdx by differences: %s", err)
+			continue
+		}
+
+		if !matrix.ApproxEquals(ODEdfdx, MattheijDfdx(x, 0.5, beta), 1e-5) {
+			t.Errorf("Incorrect dfdx by differences for scheme %d", scheme)
+		}
+
+		_, err = ode.Dfdx(matrix.Zeros(4, 1), 0.5, beta)
+		if err == nil {
+			t.Errorf("Incorrect x dim checking. Incorrect rows not detected.")
+		}
+	}
+}
+
+func TestSolveWithoutDfdx(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(101)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	MattheijBVP.ODE = NewODE(MattheijF, nil, 3, 2)
+
+	for i := range MattheijBVP.X {
+		MattheijBVP.X[i] = matrix.Zeros(3, 1)
+	}
+
+	err = (&MattheijBVP).Solve()
+
+	if err != nil {
+		t.Errorf("Error solving")
+	}
+
+	if e := mattheijMaxError(&MattheijBVP); e > 1e-4 {
+		t.Errorf("Error against exact solution too large, got %g", e)
+	}
+}
+
+func TestLorenzDfdbeta(t *testing.T) {
+	x := matrix.MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{10, 28, 8. / 3.}, 3, 1)
+	eps := 1e-6
+
+	dfdbeta := LorenzDfdbeta(x, 0, beta)
+	for k := 0; k < 3; k++ {
+		betaPlus, betaMinus := beta.Copy(), beta.Copy()
+		betaPlus.Set(k, 0, beta.Get(k, 0)+eps)
+		betaMinus.Set(k, 0, beta.Get(k, 0)-eps)
+		column := matrix.Scaled(matrix.Difference(LorenzF(x, 0, betaPlus), LorenzF(x, 0, betaMinus)), 1/(2*eps))
+
+		if !matrix.ApproxEquals(dfdbeta.DenseMatrix().GetColVector(k), column, 1e-6) {
+			t.Errorf("Incorrect LorenzDfdbeta for parameter %d", k)
+		}
+	}
+}
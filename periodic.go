@@ -0,0 +1,222 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math/cmplx"
+)
+
+// A periodic orbit found by SolvePeriodicOrbit
+type PeriodicOrbit struct {
+	X      []matrix.Matrix // orbit at the mesh points, with X[N-1] = X[0]
+	T      []float64       // mesh on [T[0], T[0] + Period]
+	Period float64
+
+	// eigenvalues of the monodromy matrix, sorted by decreasing modulus. One
+	// multiplier is close to 1, for the direction along the orbit.
+	Multipliers []complex128
+
+	Solve SolveResult
+}
+
+// Finds a periodic orbit x(t + Period) = x(t) of an autonomous ODE near
+// initialGuess, which should be roughly periodic on timeMesh and is used as
+// the reference orbit for the phase condition. Time is rescaled to [0, 1]
+// with the period as a free parameter, and the phase is fixed by the
+// integral condition
+//
+//	integral over [0, 1] of x(s).x_ref'(s) ds = 0
+//
+// which is imposed through an extra variable z' = x.x_ref' with
+// z(0) = z(1) = 0.
+func SolvePeriodicOrbit(ode ODE, initialGuess []matrix.Matrix, timeMesh []float64, beta matrix.Matrix, opts SolveOptions) (orbit PeriodicOrbit, err error) {
+	n, p, q := len(timeMesh), ode.P, ode.Q
+
+	if err = checkInitialGuess("SolvePeriodicOrbit", ode, initialGuess, n); err != nil {
+		return
+	}
+
+	if n < 3 || timeMesh[n-1] <= timeMesh[0] {
+		return orbit, MatrixError("Periodic orbit time mesh must be increasing")
+	}
+
+	if beta.Rows() != q || beta.Cols() != 1 {
+		return orbit, NewDimensionErrorIn("SolvePeriodicOrbit", "beta", q, 1, beta.Rows(), beta.Cols())
+	}
+
+	start, period := timeMesh[0], timeMesh[n-1]-timeMesh[0]
+	scaled := scaledODE(ode, start)
+
+	normalised := make([]float64, n)
+	for i := range timeMesh {
+		normalised[i] = (timeMesh[i] - start) / period
+	}
+
+	augmentedBeta := matrix.Zeros(q+1, 1)
+	for j := 0; j < q; j++ {
+		augmentedBeta.Set(j, 0, beta.Get(j, 0))
+	}
+	augmentedBeta.Set(q, 0, period)
+
+	// derivative of the reference orbit with respect to s
+	reference := make([]matrix.Matrix, n)
+	referenceF := make([]matrix.Matrix, n)
+	for i := range reference {
+		reference[i] = matrix.MakeDenseCopy(initialGuess[i])
+		referenceF[i], err = scaled.F(reference[i], normalised[i], augmentedBeta)
+		if err != nil {
+			return orbit, evaluationError("F", i, normalised[i], err)
+		}
+	}
+	dref := func(s float64) *matrix.DenseMatrix {
+		i := meshInterval(normalised, s)
+		return hermiteDerivative(reference[i], referenceF[i], reference[i+1], referenceF[i+1], normalised[i], normalised[i+1], s)
+	}
+
+	augmented := phaseODE(scaled, dref)
+
+	guess := make([]matrix.Matrix, n)
+	for i := range guess {
+		guess[i] = matrix.Zeros(p+1, 1)
+		guess[i].(*matrix.DenseMatrix).SetMatrix(0, 0, matrix.MakeDenseCopy(initialGuess[i]))
+	}
+
+	bvp, err := NewBVPWithFreeParameters(augmented, guess, normalised, periodicBoundaryCondition(p), augmentedBeta, []int{q})
+	if err != nil {
+		return
+	}
+
+	orbit.Solve, err = bvp.SolveWithOptions(opts)
+	if err != nil {
+		return
+	}
+
+	orbit.Period = bvp.Beta.Get(q, 0)
+	orbit.T = make([]float64, n)
+	orbit.X = make([]matrix.Matrix, n)
+	for i := range orbit.X {
+		orbit.T[i] = start + orbit.Period*normalised[i]
+		orbit.X[i] = matrix.MakeDenseCopy(bvp.X[i]).GetMatrix(0, 0, p, 1).Copy()
+	}
+
+	M, err := monodromy(bvp.discretization(), &scaled, orbit.X, normalised, bvp.Beta)
+	if err != nil {
+		return
+	}
+
+	orbit.Multipliers, err = eigenvalues(M)
+	return
+}
+
+// Returns the ODE for y = (x, z) with x' = F(x, s, beta) and z' = x.dref(s).
+// ode must have analytic dfdx and dfdbeta, as those from scaledODE do
+func phaseODE(ode ODE, dref func(s float64) *matrix.DenseMatrix) ODE {
+	p := ode.P
+
+	f := func(y matrix.MatrixRO, s float64, beta matrix.MatrixRO) matrix.Matrix {
+		x := matrix.MakeDenseCopy(y).GetMatrix(0, 0, p, 1)
+
+		dy := matrix.Zeros(p+1, 1)
+		dy.SetMatrix(0, 0, matrix.MakeDenseCopy(ode.f(x, s, beta)))
+		dy.Set(p, 0, matrix.Product(x.Transpose(), dref(s)).Get(0, 0))
+		return dy
+	}
+
+	dfdx := func(y matrix.MatrixRO, s float64, beta matrix.MatrixRO) matrix.Matrix {
+		x := matrix.MakeDenseCopy(y).GetMatrix(0, 0, p, 1)
+
+		J := matrix.Zeros(p+1, p+1)
+		J.SetMatrix(0, 0, matrix.MakeDenseCopy(ode.dfdx(x, s, beta)))
+		J.SetMatrix(p, 0, dref(s).Transpose())
+		return J
+	}
+
+	// z' does not depend on beta
+	dfdbeta := func(y matrix.MatrixRO, s float64, beta matrix.MatrixRO) matrix.Matrix {
+		x := matrix.MakeDenseCopy(y).GetMatrix(0, 0, p, 1)
+
+		J := matrix.Zeros(p+1, ode.Q)
+		J.SetMatrix(0, 0, matrix.MakeDenseCopy(ode.dfdbeta(x, s, beta)))
+		return J
+	}
+
+	augmented := NewODEWithParamJacobian(f, dfdx, dfdbeta, p+1, ode.Q)
+	augmented.Differencing = ode.Differencing
+	return augmented
+}
+
+// Returns the boundary conditions x(0) = x(1), z(0) = 0 and z(1) = 0 on
+// y = (x, z)
+func periodicBoundaryCondition(p int) BoundaryCondition {
+	g := func(ya, yb, beta matrix.MatrixRO) matrix.Matrix {
+		r := matrix.Zeros(p+2, 1)
+		for j := 0; j < p; j++ {
+			r.Set(j, 0, ya.Get(j, 0)-yb.Get(j, 0))
+		}
+		r.Set(p, 0, ya.Get(p, 0))
+		r.Set(p+1, 0, yb.Get(p, 0))
+		return r
+	}
+
+	dg := func(ya, yb, beta matrix.MatrixRO) (Ba, Bb matrix.Matrix) {
+		a, b := matrix.Zeros(p+2, p+1), matrix.Zeros(p+2, p+1)
+		for j := 0; j < p; j++ {
+			a.Set(j, j, 1)
+			b.Set(j, j, -1)
+		}
+		a.Set(p, p, 1)
+		b.Set(p+1, p, 1)
+		return a, b
+	}
+
+	return NewNonlinearBoundaryCondition(g, dg, p+1)
+}
+
+// Returns the product of the one step maps -B_i^-1 A_i of the discretized
+// linearisation about X, approximating the monodromy matrix
+func monodromy(disc Discretization, ode *ODE, X []matrix.Matrix, T []float64, beta matrix.MatrixRO) (M *matrix.DenseMatrix, err error) {
+	M = matrix.Eye(ode.P)
+
+	for i := 0; i < len(T)-1; i++ {
+		A, B, err := disc.Jacobian(ode, X[i], X[i+1], T[i], T[i+1], beta)
+		if err != nil {
+			return nil, err
+		}
+
+		step, err := solveColumns(B, matrix.Scaled(A, -1))
+		if err != nil {
+			return nil, err
+		}
+
+		M = matrix.Product(step, M)
+	}
+	return
+}
+
+// Returns the eigenvalues of a square matrix sorted by decreasing modulus
+func eigenvalues(M *matrix.DenseMatrix) (lambda []complex128, err error) {
+	_, D, err := M.Eigen()
+	if err != nil {
+		return
+	}
+
+	n := D.Rows()
+	lambda = make([]complex128, n)
+	for i := 0; i < n; i++ {
+		// complex pairs are held in 2 by 2 blocks of D
+		var im float64
+		if i+1 < n && D.Get(i, i+1) > 0 {
+			im = D.Get(i, i+1)
+		} else if i > 0 && D.Get(i, i-1) < 0 {
+			im = D.Get(i, i-1)
+		}
+		lambda[i] = complex(D.Get(i, i), im)
+	}
+
+	// insertion sort by modulus
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && cmplx.Abs(lambda[j]) > cmplx.Abs(lambda[j-1]); j-- {
+			lambda[j], lambda[j-1] = lambda[j-1], lambda[j]
+		}
+	}
+	return
+}
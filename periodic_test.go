@@ -0,0 +1,97 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// Hopf normal form, with the stable limit cycle x^2 + y^2 = 1 of period 2 pi
+var hopfODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		u, v := x.Get(0, 0), x.Get(1, 0)
+		r2 := u*u + v*v
+		return matrix.MakeDenseMatrix([]float64{u - v - u*r2, u + v - v*r2}, 2, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		u, v := x.Get(0, 0), x.Get(1, 0)
+		r2 := u*u + v*v
+		return matrix.MakeDenseMatrix([]float64{
+			1 - r2 - 2*u*u, -1 - 2*u*v,
+			1 - 2*u*v, 1 - r2 - 2*v*v,
+		}, 2, 2)
+	},
+	2, 0,
+)
+
+func TestSolvePeriodicOrbit(t *testing.T) {
+	n := 201
+	period := 7.
+
+	timeMesh := make([]float64, n)
+	initialGuess := make([]matrix.Matrix, n)
+	for i := range timeMesh {
+		timeMesh[i] = period * float64(i) / float64(n-1)
+		theta := 2 * math.Pi * float64(i) / float64(n-1)
+		initialGuess[i] = matrix.MakeDenseMatrix([]float64{1.2 * math.Cos(theta), 1.2 * math.Sin(theta)}, 2, 1)
+	}
+
+	orbit, err := SolvePeriodicOrbit(hopfODE, initialGuess, timeMesh, matrix.Zeros(0, 1), DefaultSolveOptions())
+	if err != nil {
+		t.Errorf("Error solving for periodic orbit: %s", err)
+		return
+	}
+
+	if math.Abs(orbit.Period-2*math.Pi) > 1e-3 {
+		t.Errorf("Period %f, expected %f", orbit.Period, 2*math.Pi)
+	}
+
+	for i, x := range orbit.X {
+		r := math.Hypot(x.Get(0, 0), x.Get(1, 0))
+		if math.Abs(r-1) > 1e-3 {
+			t.Errorf("Radius %f at t = %f, expected 1", r, orbit.T[i])
+			break
+		}
+	}
+
+	if len(orbit.Multipliers) != 2 {
+		t.Errorf("Expected 2 multipliers, got %d", len(orbit.Multipliers))
+		return
+	}
+
+	if cmplx.Abs(orbit.Multipliers[0]-1) > 1e-3 {
+		t.Errorf("Trivial multiplier %v, expected 1", orbit.Multipliers[0])
+	}
+
+	if cmplx.Abs(orbit.Multipliers[1]-complex(math.Exp(-4*math.Pi), 0)) > 1e-4 {
+		t.Errorf("Stable multiplier %v, expected %g", orbit.Multipliers[1], math.Exp(-4*math.Pi))
+	}
+}
+
+func TestEigenvaluesComplex(t *testing.T) {
+	// rotation by pi/3 scaled by 2, and a real eigenvalue 1/2
+	c, s := math.Cos(math.Pi/3), math.Sin(math.Pi/3)
+	M := matrix.MakeDenseMatrix([]float64{
+		2 * c, -2 * s, 0,
+		2 * s, 2 * c, 0,
+		0, 0, 0.5,
+	}, 3, 3)
+
+	lambda, err := eigenvalues(M)
+	if err != nil {
+		t.Errorf("Error computing eigenvalues: %s", err)
+		return
+	}
+
+	expected := []complex128{2 * cmplx.Exp(complex(0, math.Pi/3)), 2 * cmplx.Exp(complex(0, -math.Pi/3)), 0.5}
+	if cmplx.Abs(lambda[0]-expected[1]) < 1e-10 {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+
+	for i := range expected {
+		if cmplx.Abs(lambda[i]-expected[i]) > 1e-10 {
+			t.Errorf("Eigenvalue %d is %v, expected %v", i, lambda[i], expected[i])
+		}
+	}
+}
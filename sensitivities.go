@@ -0,0 +1,85 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+// Returns the sensitivities of the discrete solution to the parameters and
+// the boundary vector, dxdbeta[i] = dX[i]/dBeta (P by Q) and dxdb[i] = dX[i]/db
+// (P by P), where the boundary conditions are taken as g = b. The BVP should
+// already be solved. Each column is found by applying
+// a single factorisation of the Newton matrix to -dG/dtheta, where G is the
+// stacked ConstraintVectorBlocks.
+func (bvp *BVP) Sensitivities() (dxdbeta, dxdb []*matrix.DenseMatrix, err error) {
+	n, p, q := bvp.N, bvp.ODE.P, bvp.ODE.Q
+
+	if len(bvp.FreeParameters) > 0 {
+		return nil, nil, MatrixError("Sensitivities does not support free parameters")
+	}
+
+	drdbeta := make([]*matrix.DenseMatrix, n-1)
+	disc := bvp.discretization()
+	for i := 0; i < n-1; i++ {
+		drdbeta[i], err = disc.ParameterJacobian(&bvp.ODE, bvp.X[i], bvp.X[i+1], bvp.T[i], bvp.T[i+1], bvp.Beta)
+		if err != nil {
+			return
+		}
+	}
+
+	// linear boundary conditions do not depend on beta
+	dgdbeta := matrix.Zeros(p, q)
+	if bvp.BC != nil && bvp.Multipoint == nil && q > 0 {
+		dgdbeta, err = centralDifference(func(v matrix.MatrixRO) (matrix.Matrix, error) {
+			return bvp.BC.Residual(bvp.X[0], bvp.X[n-1], v)
+		}, bvp.Beta)
+		if err != nil {
+			return
+		}
+	}
+
+	f, err := factorise(bvp)
+	if err != nil {
+		return
+	}
+
+	dxdbeta = make([]*matrix.DenseMatrix, n)
+	dxdb = make([]*matrix.DenseMatrix, n)
+	for i := 0; i < n; i++ {
+		dxdbeta[i] = matrix.Zeros(p, q)
+		dxdb[i] = matrix.Zeros(p, p)
+	}
+
+	for k := 0; k < q; k++ {
+		c := make([]*matrix.DenseMatrix, n)
+		for i := 0; i < n-1; i++ {
+			c[i] = matrix.Scaled(drdbeta[i].GetColVector(k), -1)
+		}
+		c[n-1] = matrix.Scaled(dgdbeta.GetColVector(k), -1)
+
+		column, err := f.solve(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := 0; i < n; i++ {
+			dxdbeta[i].SetMatrix(0, k, column[i])
+		}
+	}
+
+	for k := 0; k < p; k++ {
+		c := make([]*matrix.DenseMatrix, n)
+		for i := 0; i < n-1; i++ {
+			c[i] = matrix.Zeros(p, 1)
+		}
+		c[n-1] = matrix.Zeros(p, 1) // -d(g - b)/db = I
+		c[n-1].Set(k, 0, 1)
+
+		column, err := f.solve(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := 0; i < n; i++ {
+			dxdb[i].SetMatrix(0, k, column[i])
+		}
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestSensitivitiesMattheij(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(51)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	opts := DefaultSolveOptions()
+	opts.AbsTol = 1e-12
+
+	_, err = (&MattheijBVP).SolveWithOptions(opts)
+
+	if err != nil {
+		t.Errorf("Error solving")
+	}
+
+	dxdbeta, dxdb, err := (&MattheijBVP).Sensitivities()
+
+	if err != nil {
+		t.Errorf("Error calculating sensitivities: %s", err)
+		return
+	}
+
+	if len(dxdbeta) != MattheijBVP.N || dxdbeta[0].Rows() != 3 || dxdbeta[0].Cols() != 2 {
+		t.Errorf("Incorrect dims for dxdbeta")
+	}
+
+	if len(dxdb) != MattheijBVP.N || dxdb[0].Rows() != 3 || dxdb[0].Cols() != 3 {
+		t.Errorf("Incorrect dims for dxdb")
+	}
+
+	// compare against re-solving with perturbed parameters
+	eps := 1e-6
+	for k := 0; k < 5; k++ {
+		perturbed, _ := newMattheijBVP(51)
+		if k < 2 {
+			perturbed.Beta.Set(k, 0, perturbed.Beta.Get(k, 0)+eps)
+		} else {
+			perturbed.B.Set(k-2, 0, perturbed.B.Get(k-2, 0)+eps)
+		}
+
+		_, err = (&perturbed).SolveWithOptions(opts)
+
+		if err != nil {
+			t.Errorf("Error solving perturbed BVP")
+		}
+
+		for i := 0; i < MattheijBVP.N; i += 10 {
+			for j := 0; j < 3; j++ {
+				difference := (perturbed.X[i].Get(j, 0) - MattheijBVP.X[i].Get(j, 0)) / eps
+
+				var analytic float64
+				if k < 2 {
+					analytic = dxdbeta[i].Get(j, k)
+				} else {
+					analytic = dxdb[i].Get(j, k-2)
+				}
+
+				if math.Abs(difference-analytic) > 1e-4*(1+math.Abs(analytic)) {
+					t.Errorf("Incorrect sensitivity of x_%d(t_%d) to parameter %d, expected %g, got %g", j, i, k, difference, analytic)
+				}
+			}
+		}
+	}
+}
+
+func TestSensitivitiesWithoutDfdbeta(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(11)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	dxdbeta, _, err := (&MattheijBVP).Sensitivities()
+
+	if err != nil {
+		t.Errorf("Error calculating sensitivities: %s", err)
+	}
+
+	MattheijBVP.ODE = NewODE(MattheijF, MattheijDfdx, 3, 2)
+	MattheijBVP.ODE.Differencing = CentralDifference
+
+	differenced, _, err := (&MattheijBVP).Sensitivities()
+
+	if err != nil {
+		t.Errorf("Error calculating sensitivities by differences: %s", err)
+	}
+
+	for i := range dxdbeta {
+		if !matrix.ApproxEquals(dxdbeta[i], differenced[i], 1e-6) {
+			t.Errorf("Sensitivities by differences disagree at mesh point %d", i)
+		}
+	}
+}
+
+func TestParameterJacobian(t *testing.T) {
+	discretizations := []Discretization{Trapezoidal{}, HermiteSimpson{}, GaussLegendre4, LobattoIIIA6}
+
+	xi := matrix.MakeDenseMatrix([]float64{1, 0.5, -0.3}, 3, 1)
+	xj := matrix.MakeDenseMatrix([]float64{1.2, 0.4, -0.1}, 3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{19, 2}, 2, 1)
+	ti, tj := 0.2, 0.3
+	eps := 1e-6
+
+	for _, disc := range discretizations {
+		drdbeta, err := disc.ParameterJacobian(&MattheijODE, xi, xj, ti, tj, beta)
+
+		if err != nil {
+			t.Errorf("Error calculating parameter Jacobian: %s", err)
+			continue
+		}
+
+		for k := 0; k < 2; k++ {
+			betaPlus, betaMinus := beta.Copy(), beta.Copy()
+			betaPlus.Set(k, 0, beta.Get(k, 0)+eps)
+			betaMinus.Set(k, 0, beta.Get(k, 0)-eps)
+			rPlus, _ := disc.Residual(&MattheijODE, xi, xj, ti, tj, betaPlus)
+			rMinus, _ := disc.Residual(&MattheijODE, xi, xj, ti, tj, betaMinus)
+
+			for j := 0; j < 3; j++ {
+				if math.Abs((rPlus.Get(j, 0)-rMinus.Get(j, 0))/(2*eps)-drdbeta.Get(j, k)) > 1e-6 {
+					t.Errorf("Order %d discretization: incorrect dr/dbeta(%d, %d)", disc.Order(), j, k)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+// Options for SolveMultipleShooting
+type ShootingOptions struct {
+	// Mesh indices of the shooting nodes, increasing from 0 to N-1. If nil,
+	// Intervals shooting intervals with about equal numbers of mesh points
+	// are used.
+	Nodes     []int
+	Intervals int
+
+	Substeps int // RK4 steps taken across each mesh interval
+
+	Solve SolveOptions // tolerances and limits for the Newton iteration
+}
+
+func DefaultShootingOptions() ShootingOptions {
+	return ShootingOptions{
+		Nodes:     nil,
+		Intervals: 10,
+		Substeps:  4,
+		Solve:     DefaultSolveOptions(),
+	}
+}
+
+// Solves the BVP by multiple shooting. The values of X at the shooting nodes
+// are the unknowns. Each shooting interval is integrated by RK4 along with
+// the variational equations Phi' = Dfdx Phi, and the matching conditions and
+// boundary conditions are solved by damped Newton iteration using the same
+// block bidiagonal factorisation as SolveWithOptions. On exit X holds the
+// integrated trajectory on the original mesh. Discretization is not used.
+func (bvp *BVP) SolveMultipleShooting(opts ShootingOptions) (result SolveResult, err error) {
+	if len(bvp.FreeParameters) > 0 || bvp.Multipoint != nil {
+		return result, MatrixError("Multiple shooting does not support free parameters or multipoint boundary conditions")
+	}
+
+	if opts.Substeps < 1 {
+		return result, MatrixError("Multiple shooting needs at least one substep per mesh interval")
+	}
+
+	nodes, err := bvp.shootingNodes(opts)
+	if err != nil {
+		return
+	}
+
+	residual, err := bvp.shootingResidual(nodes, opts.Substeps)
+	if err != nil {
+		return
+	}
+
+	cost := sumOfSquares(residual)
+	result.Cost = cost
+
+	for ; result.Iterations < opts.Solve.MaxIterations; result.Iterations++ {
+		delta, err := bvp.shootingDelta(nodes, opts.Substeps)
+		if err != nil {
+			return result, err
+		}
+
+		result.StepNorm = math.Sqrt(sumOfSquares(delta))
+
+		yold := make([]matrix.Matrix, len(nodes))
+		for k, i := range nodes {
+			yold[k] = matrix.MakeDenseCopy(bvp.X[i])
+		}
+
+		if !exceedsTolerance(delta, yold, opts.Solve.AbsTol, opts.Solve.RelTol) {
+			result.Reason = Converged
+			return result, nil
+		}
+
+		costold := cost
+		accepted, tooSmall := false, false
+
+		alpha := 1.
+		for k := 0; k <= opts.Solve.MaxLineSearch; k++ {
+			if k > 0 {
+				// scale delta since cost increased
+				alpha = alpha / 2
+				result.Halvings++
+
+				for j := range delta {
+					delta[j].Scale(0.5)
+				}
+				result.StepNorm = result.StepNorm / 2
+
+				if alpha < opts.Solve.MinStepFactor {
+					tooSmall = true
+					break
+				}
+
+				if !exceedsTolerance(delta, yold, opts.Solve.AbsTol, opts.Solve.RelTol) {
+					result.Cost = costold
+					result.Reason = Stalled
+					return result, bvp.restoreShootingNodes(nodes, yold, opts.Substeps)
+				}
+			}
+
+			for j, i := range nodes {
+				bvp.X[i] = matrix.Difference(yold[j], delta[j])
+			}
+
+			residual, err = bvp.shootingResidual(nodes, opts.Substeps)
+			if err != nil {
+				return result, err
+			}
+			cost = sumOfSquares(residual)
+
+			if cost < costold {
+				accepted = true
+				break
+			}
+		}
+
+		if !accepted {
+			result.Cost = costold
+			if err := bvp.restoreShootingNodes(nodes, yold, opts.Substeps); err != nil {
+				return result, err
+			}
+
+			if tooSmall {
+				result.Reason = StepTooSmall
+				return result, NonConvergenceError{"Multiple shooting line search step too small", result.Iterations, result.Cost, result.StepNorm}
+			}
+			result.Reason = LineSearchFailed
+			return result, NonConvergenceError{"Multiple shooting line search failed", result.Iterations, result.Cost, result.StepNorm}
+		}
+		result.Cost = cost
+		opts.Solve.report(result)
+	}
+
+	result.Reason = MaxIterations
+	return result, NonConvergenceError{"Warning: multiple shooting did not terminate", result.Iterations, result.Cost, result.StepNorm}
+}
+
+// Returns the shooting nodes given by opts, checking that they are
+// increasing mesh indices from 0 to N-1
+func (bvp *BVP) shootingNodes(opts ShootingOptions) ([]int, error) {
+	n := bvp.N
+
+	if opts.Nodes == nil {
+		k := opts.Intervals
+		if k < 1 {
+			return nil, MatrixError("Multiple shooting needs at least one interval")
+		}
+		if k > n-1 {
+			k = n - 1
+		}
+
+		nodes := make([]int, k+1)
+		for j := range nodes {
+			nodes[j] = j * (n - 1) / k
+		}
+		return nodes, nil
+	}
+
+	nodes := opts.Nodes
+	if len(nodes) < 2 || nodes[0] != 0 || nodes[len(nodes)-1] != n-1 {
+		return nil, MatrixError("Shooting nodes must start at 0 and end at N-1")
+	}
+	for j := 1; j < len(nodes); j++ {
+		if nodes[j] <= nodes[j-1] {
+			return nil, MatrixError("Shooting nodes must be increasing")
+		}
+	}
+
+	copied := make([]int, len(nodes))
+	copy(copied, nodes)
+	return copied, nil
+}
+
+// Integrates from each shooting node to the next, overwriting X at the mesh
+// points strictly between nodes. Returns the state reached at the end of each
+// shooting interval and, if variational is set, the fundamental matrix of the
+// linearisation across it.
+func (bvp *BVP) shoot(nodes []int, substeps int, variational bool) (ends, Phi []*matrix.DenseMatrix, err error) {
+	k := len(nodes) - 1
+
+	ends = make([]*matrix.DenseMatrix, k)
+	if variational {
+		Phi = make([]*matrix.DenseMatrix, k)
+	}
+
+	for j := 0; j < k; j++ {
+		x := matrix.MakeDenseCopy(bvp.X[nodes[j]])
+
+		var phi *matrix.DenseMatrix
+		if variational {
+			phi = matrix.Eye(bvp.ODE.P)
+		}
+
+		for i := nodes[j]; i < nodes[j+1]; i++ {
+			h := (bvp.T[i+1] - bvp.T[i]) / float64(substeps)
+
+			for s := 0; s < substeps; s++ {
+				x, phi, err = rk4Step(&bvp.ODE, i, x, phi, bvp.T[i]+float64(s)*h, h, bvp.Beta)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			if i+1 < nodes[j+1] {
+				bvp.X[i+1] = x.Copy()
+			}
+		}
+
+		ends[j] = x
+		if variational {
+			Phi[j] = phi
+		}
+	}
+	return
+}
+
+// Returns the matching conditions, one block per shooting interval, followed
+// by the boundary conditions
+func (bvp *BVP) shootingResidual(nodes []int, substeps int) (residual []*matrix.DenseMatrix, err error) {
+	ends, _, err := bvp.shoot(nodes, substeps, false)
+	if err != nil {
+		return
+	}
+
+	residual = make([]*matrix.DenseMatrix, len(nodes))
+	for j := range ends {
+		residual[j] = matrix.Difference(ends[j], bvp.X[nodes[j+1]])
+	}
+
+	residual[len(nodes)-1], err = bvp.boundaryResidual()
+	return
+}
+
+// Returns the Newton step for the values of X at the shooting nodes
+func (bvp *BVP) shootingDelta(nodes []int, substeps int) (delta []*matrix.DenseMatrix, err error) {
+	n, m := len(nodes), bvp.ODE.P
+
+	ends, Phi, err := bvp.shoot(nodes, substeps, true)
+	if err != nil {
+		return
+	}
+
+	A := make([]*matrix.DenseMatrix, n-1)
+	B := make([]*matrix.DenseMatrix, n-1)
+	c := make([]*matrix.DenseMatrix, n)
+	for j := 0; j < n-1; j++ {
+		A[j] = Phi[j]
+		B[j] = matrix.Scaled(matrix.Eye(m), -1)
+		c[j] = matrix.Difference(ends[j], bvp.X[nodes[j+1]])
+	}
+
+	c[n-1], err = bvp.boundaryResidual()
+	if err != nil {
+		return
+	}
+
+	Ba, Bb, err := bvp.boundaryJacobian()
+	if err != nil {
+		return
+	}
+
+	// indices of the factorisation are of shooting nodes, not mesh points
+	f := factoriseBlocks(A, B, n, m, 0)
+	if i := f.singularPivot(); i >= 0 {
+		return nil, SingularSystemError{nodes[i+1]}
+	}
+	f.ends.SetMatrix(m, 0, Bb)
+	f.ends.SetMatrix(m, m, Ba)
+
+	delta, err = f.solve(c)
+	if singErr, ok := err.(SingularSystemError); ok {
+		return nil, SingularSystemError{nodes[singErr.Index]}
+	}
+	return
+}
+
+// Resets X at the shooting nodes to y and integrates between them
+func (bvp *BVP) restoreShootingNodes(nodes []int, y []matrix.Matrix, substeps int) error {
+	for k, i := range nodes {
+		bvp.X[i] = matrix.MakeDenseCopy(y[k])
+	}
+	_, _, err := bvp.shoot(nodes, substeps, false)
+	return err
+}
+
+// Takes one classical Runge-Kutta step of size h from x at time t within
+// mesh interval index. If phi is not nil the variational equation
+// phi' = Dfdx phi is stepped along with x.
+func rk4Step(ode *ODE, index int, x, phi *matrix.DenseMatrix, t, h float64, beta matrix.MatrixRO) (xnew, phinew *matrix.DenseMatrix, err error) {
+	stage := func(x, phi *matrix.DenseMatrix, t float64) (dx, dphi *matrix.DenseMatrix, err error) {
+		f, err := ode.F(x, t, beta)
+		if err != nil {
+			return nil, nil, evaluationError("F", index, t, err)
+		}
+		dx = matrix.MakeDenseCopy(f)
+
+		if phi != nil {
+			J, err := ode.Dfdx(x, t, beta)
+			if err != nil {
+				return nil, nil, evaluationError("Dfdx", index, t, err)
+			}
+			dphi = matrix.Product(J, phi)
+		}
+		return
+	}
+
+	// returns a + h b, or nil if a is nil
+	step := func(a, b *matrix.DenseMatrix, h float64) *matrix.DenseMatrix {
+		if a == nil {
+			return nil
+		}
+		return matrix.Sum(a, matrix.Scaled(b, h))
+	}
+
+	k1, l1, err := stage(x, phi, t)
+	if err != nil {
+		return
+	}
+	k2, l2, err := stage(step(x, k1, h/2), step(phi, l1, h/2), t+h/2)
+	if err != nil {
+		return
+	}
+	k3, l3, err := stage(step(x, k2, h/2), step(phi, l2, h/2), t+h/2)
+	if err != nil {
+		return
+	}
+	k4, l4, err := stage(step(x, k3, h), step(phi, l3, h), t+h)
+	if err != nil {
+		return
+	}
+
+	xnew = matrix.Sum(x, matrix.Scaled(matrix.Sum(k1, matrix.Scaled(k2, 2), matrix.Scaled(k3, 2), k4), h/6))
+	if phi != nil {
+		phinew = matrix.Sum(phi, matrix.Scaled(matrix.Sum(l1, matrix.Scaled(l2, 2), matrix.Scaled(l3, 2), l4), h/6))
+	}
+	return
+}
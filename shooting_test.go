@@ -0,0 +1,103 @@
+package bvp
+
+import (
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestSolveMultipleShootingMattheij(t *testing.T) {
+	bvp, err := newMattheijBVP(41)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP: %s", err)
+		return
+	}
+
+	for i := range bvp.X {
+		bvp.X[i] = matrix.Zeros(3, 1)
+	}
+
+	opts := DefaultShootingOptions()
+	opts.Solve.AbsTol = 1e-10
+
+	result, err := bvp.SolveMultipleShooting(opts)
+	if err != nil {
+		t.Errorf("Error solving by multiple shooting: %s", err)
+		return
+	}
+
+	if result.Reason != Converged {
+		t.Errorf("Expected convergence, got %s", result.Reason)
+	}
+
+	// the exact solution is exp(t) in every component, and X should hold it
+	// on the whole mesh
+	for i := range bvp.X {
+		for j := 0; j < 3; j++ {
+			if e := math.Abs(bvp.X[i].Get(j, 0) - math.Exp(bvp.T[i])); e > 1e-6 {
+				t.Errorf("Error %g at t = %f", e, bvp.T[i])
+				return
+			}
+		}
+	}
+}
+
+func TestSolveMultipleShootingNodes(t *testing.T) {
+	bvp, err := newOscillatorMultipointBVP(21, []int{0, 20})
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	opts := DefaultShootingOptions()
+	opts.Nodes = []int{0, 5, 20}
+
+	if _, err := bvp.SolveMultipleShooting(opts); err == nil {
+		t.Errorf("Expected an error for a multipoint BVP")
+	}
+
+	bvp.Multipoint = nil
+	bvp.BC = NewNonlinearBoundaryCondition(
+		func(xa, xb, beta matrix.MatrixRO) matrix.Matrix {
+			return matrix.MakeDenseMatrix([]float64{xa.Get(0, 0), xb.Get(0, 0) - math.Sin(2)}, 2, 1)
+		},
+		nil, 2,
+	)
+
+	for _, nodes := range [][]int{{0, 20, 20}, {1, 20}, {0, 5}} {
+		opts.Nodes = nodes
+		if _, err := bvp.SolveMultipleShooting(opts); err == nil {
+			t.Errorf("Expected an error for shooting nodes %v", nodes)
+		}
+	}
+
+	opts.Nodes = []int{0, 5, 20}
+	if _, err := bvp.SolveMultipleShooting(opts); err != nil {
+		t.Errorf("Error solving by multiple shooting: %s", err)
+		return
+	}
+
+	if e := oscillatorMaxError(&bvp); e > 1e-6 {
+		t.Errorf("Maximum error %g", e)
+	}
+}
+
+func TestSolveMultipleShootingSingular(t *testing.T) {
+	bvp, err := newMattheijBVP(41)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP: %s", err)
+		return
+	}
+
+	// no boundary conditions, so the end system is singular
+	bvp.B0 = matrix.Zeros(3, 3)
+	bvp.B1 = matrix.Zeros(3, 3)
+
+	_, err = bvp.SolveMultipleShooting(DefaultShootingOptions())
+
+	var singErr SingularSystemError
+	if !errors.As(err, &singErr) || singErr.Index != bvp.N-1 {
+		t.Errorf("Expected a SingularSystemError at mesh index %d, got %v", bvp.N-1, err)
+	}
+}
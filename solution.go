@@ -0,0 +1,86 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+// A continuous solution built from values X and derivatives F on the mesh T,
+// interpolated by cubic Hermite polynomials between mesh points. Outside
+// [T[0], T[N-1]] the end polynomials are extrapolated.
+type Solution struct {
+	T []float64
+	X []matrix.Matrix
+	F []matrix.Matrix
+}
+
+// Returns the interpolant of X on T with derivatives from ode.F
+func NewSolution(ode *ODE, X []matrix.Matrix, T []float64, beta matrix.MatrixRO) (*Solution, error) {
+	n := len(T)
+
+	if n < 2 || len(X) != n {
+		return nil, MatrixError("Solution needs at least two mesh points and one value of X per point")
+	}
+
+	s := &Solution{make([]float64, n), make([]matrix.Matrix, n), make([]matrix.Matrix, n)}
+	copy(s.T, T)
+
+	for i := range T {
+		s.X[i] = matrix.MakeDenseCopy(X[i])
+
+		f, err := ode.F(X[i], T[i], beta)
+		if err != nil {
+			return nil, evaluationError("F", i, T[i], err)
+		}
+		s.F[i] = f
+	}
+	return s, nil
+}
+
+// Returns the continuous solution of the BVP from its current X. For a BVP
+// created by NewFreeBoundaryBVP it is in the original time on PhysicalMesh.
+func (bvp *BVP) Solution() (*Solution, error) {
+	s, err := NewSolution(&bvp.ODE, bvp.X, bvp.T, bvp.Beta)
+	if err != nil {
+		return nil, err
+	}
+
+	if bvp.freeBoundary != nil {
+		// dx/dt = (dx/ds) / L
+		length := bvp.Beta.Get(bvp.freeBoundary.index, 0)
+		s.T = bvp.PhysicalMesh()
+		for i := range s.F {
+			s.F[i] = matrix.Scaled(s.F[i], 1/length)
+		}
+	}
+	return s, nil
+}
+
+// Returns the solution at t
+func (s *Solution) Eval(t float64) *matrix.DenseMatrix {
+	i := meshInterval(s.T, t)
+	return hermite(s.X[i], s.F[i], s.X[i+1], s.F[i+1], s.T[i], s.T[i+1], t)
+}
+
+// Returns the derivative of the interpolant at t
+func (s *Solution) EvalDerivative(t float64) *matrix.DenseMatrix {
+	i := meshInterval(s.T, t)
+	return hermiteDerivative(s.X[i], s.F[i], s.X[i+1], s.F[i+1], s.T[i], s.T[i+1], t)
+}
+
+// Returns the solution at each of the times, one column per time
+func (s *Solution) Sample(times []float64) *matrix.DenseMatrix {
+	samples := matrix.Zeros(s.X[0].Rows(), len(times))
+	for k, t := range times {
+		samples.SetMatrix(0, k, s.Eval(t))
+	}
+	return samples
+}
+
+// Returns the solution at each of the times in the layout of BVP.X
+func (s *Solution) Resample(times []float64) []matrix.Matrix {
+	X := make([]matrix.Matrix, len(times))
+	for k, t := range times {
+		X[k] = s.Eval(t)
+	}
+	return X
+}
@@ -0,0 +1,95 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+func TestSolutionEval(t *testing.T) {
+	bvp, err := newOscillatorMultipointBVP(41, []int{0, 40})
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	if err := bvp.Solve(); err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+		return
+	}
+
+	s, err := bvp.Solution()
+	if err != nil {
+		t.Errorf("Error creating solution: %s", err)
+		return
+	}
+
+	times := []float64{0, 0.01, 0.333, 1, 1.2345, 1.99, 2}
+	for _, tt := range times {
+		if e := math.Abs(s.Eval(tt).Get(0, 0) - math.Sin(tt)); e > 1e-3 {
+			t.Errorf("Error %g at t = %f", e, tt)
+		}
+		if e := math.Abs(s.EvalDerivative(tt).Get(0, 0) - math.Cos(tt)); e > 1e-3 {
+			t.Errorf("Derivative error %g at t = %f", e, tt)
+		}
+	}
+
+	// the interpolant passes through X at the mesh points
+	for i := range bvp.T {
+		if d := matrix.Difference(s.Eval(bvp.T[i]), bvp.X[i]).TwoNorm(); d > 1e-12 {
+			t.Errorf("Interpolant differs from X by %g at t = %f", d, bvp.T[i])
+		}
+	}
+
+	samples := s.Sample(times)
+	X := s.Resample(times)
+	if samples.Rows() != 2 || samples.Cols() != len(times) || len(X) != len(times) {
+		t.Errorf("Unexpected sample dimensions")
+		return
+	}
+
+	for k := range times {
+		if d := matrix.Difference(samples.GetColVector(k), X[k]).TwoNorm(); d != 0 {
+			t.Errorf("Sample and Resample differ at t = %f", times[k])
+		}
+	}
+}
+
+func TestSolutionFreeBoundary(t *testing.T) {
+	bvp, err := newTimeToTargetBVP(101)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	if err := bvp.Solve(); err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+		return
+	}
+
+	s, err := bvp.Solution()
+	if err != nil {
+		t.Errorf("Error creating solution: %s", err)
+		return
+	}
+
+	if e := math.Abs(s.T[len(s.T)-1] - math.Pi/6); e > 1e-4 {
+		t.Errorf("Solution ends at %f, expected %f", s.T[len(s.T)-1], math.Pi/6)
+	}
+
+	for _, tt := range []float64{0.1, 0.3, 0.5} {
+		if e := math.Abs(s.Eval(tt).Get(0, 0) - math.Sin(tt)); e > 1e-4 {
+			t.Errorf("Error %g at t = %f", e, tt)
+		}
+		if e := math.Abs(s.EvalDerivative(tt).Get(0, 0) - math.Cos(tt)); e > 1e-4 {
+			t.Errorf("Derivative error %g at t = %f", e, tt)
+		}
+	}
+}
+
+func TestNewSolutionDimensions(t *testing.T) {
+	X := []matrix.Matrix{matrix.Zeros(2, 1)}
+	if _, err := NewSolution(&oscillatorODE, X, []float64{0, 1}, matrix.Zeros(1, 1)); err == nil {
+		t.Errorf("Expected an error for mismatched X and T")
+	}
+}
@@ -0,0 +1,85 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+)
+
+// Options controlling the damped Newton iteration in SolveWithOptions
+type SolveOptions struct {
+	// A Newton step delta is accepted as converged when every component
+	// satisfies |delta| <= AbsTol + RelTol*|x|
+	AbsTol, RelTol float64
+
+	MaxIterations int // maximum number of Newton iterations
+	MaxLineSearch int // maximum number of step halvings per iteration
+
+	// The line search gives up once the step has been scaled below this
+	// factor of the full Newton step. Zero disables the check.
+	MinStepFactor float64
+
+	// Optional callback after each accepted Newton iteration, passed the
+	// number of iterations taken and the cost and step norm after it
+	Progress func(iteration int, cost, stepNorm float64)
+}
+
+// Returns the options used by Solve
+func DefaultSolveOptions() SolveOptions {
+	return SolveOptions{
+		AbsTol:        1e-6,
+		RelTol:        0,
+		MaxIterations: 500,
+		MaxLineSearch: 100,
+		MinStepFactor: 0,
+	}
+}
+
+type TerminationReason int
+
+const (
+	NotTerminated    TerminationReason = iota
+	Converged                          // full Newton step within tolerance
+	Stalled                            // damped Newton step within tolerance
+	MaxIterations                      // iteration limit reached
+	LineSearchFailed                   // no decrease in cost within MaxLineSearch halvings
+	StepTooSmall                       // damped step fell below MinStepFactor
+	Cancelled                          // context cancelled between iterations
+)
+
+func (tr TerminationReason) String() string {
+	switch tr {
+	case NotTerminated:
+		return "not terminated"
+	case Converged:
+		return "converged"
+	case Stalled:
+		return "stalled"
+	case MaxIterations:
+		return "maximum iterations reached"
+	case LineSearchFailed:
+		return "line search failed"
+	case StepTooSmall:
+		return "step too small"
+	case Cancelled:
+		return "cancelled"
+	}
+	return "unknown"
+}
+
+// Report of a call to SolveWithOptions
+type SolveResult struct {
+	Iterations int     // number of Newton iterations taken
+	Cost       float64 // sum of squares of ConstraintVectorBlocks at the final X
+	StepNorm   float64 // euclidean norm of the last Newton step
+	Halvings   int     // total number of line search step halvings
+	Reason     TerminationReason
+
+	// final values of the entries of Beta listed in BVP.FreeParameters
+	FreeParameters *matrix.DenseMatrix
+}
+
+// Calls Progress, if set, for the iteration just accepted
+func (opts SolveOptions) report(result SolveResult) {
+	if opts.Progress != nil {
+		opts.Progress(result.Iterations+1, result.Cost, result.StepNorm)
+	}
+}
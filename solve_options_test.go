@@ -0,0 +1,111 @@
+package bvp
+
+import (
+	"context"
+	"errors"
+	"github.com/sbroadfoot90/go.matrix"
+	"testing"
+)
+
+func TestSolveWithOptionsMattheij(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(101)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	result, err := (&MattheijBVP).SolveWithOptions(DefaultSolveOptions())
+
+	if err != nil {
+		t.Errorf("Error solving: %s", err)
+	}
+
+	if result.Reason != Converged {
+		t.Errorf("Incorrect termination reason, expected %s, got %s", Converged, result.Reason)
+	}
+
+	if result.Iterations == 0 {
+		t.Errorf("Expected at least one iteration")
+	}
+
+	if result.Cost > 1e-10 {
+		t.Errorf("Final cost too large, got %g", result.Cost)
+	}
+}
+
+func TestSolveWithOptionsMaxIterations(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(101)
+
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+	}
+
+	// far from the solution, so the first step is accepted
+	for i := range MattheijBVP.X {
+		MattheijBVP.X[i] = matrix.Zeros(3, 1)
+	}
+
+	opts := DefaultSolveOptions()
+	opts.AbsTol = 0
+	opts.MaxIterations = 1
+
+	result, err := (&MattheijBVP).SolveWithOptions(opts)
+
+	var convErr NonConvergenceError
+	if !errors.As(err, &convErr) {
+		t.Errorf("Expected a NonConvergenceError when the iteration limit is reached, got %v", err)
+	}
+
+	if result.Reason != MaxIterations {
+		t.Errorf("Incorrect termination reason, expected %s, got %s", MaxIterations, result.Reason)
+	}
+}
+
+func TestSolveProgressAndCancellation(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(21, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+	for i := range bvp.X {
+		bvp.X[i] = matrix.Zeros(2, 1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var accepted []matrix.Matrix
+	calls := 0
+
+	opts := DefaultSolveOptions()
+	opts.Progress = func(iteration int, cost, stepNorm float64) {
+		calls++
+		if iteration != calls || stepNorm <= 0 {
+			t.Errorf("Unexpected progress iteration %d, cost %g, step norm %g", iteration, cost, stepNorm)
+		}
+
+		accepted = make([]matrix.Matrix, len(bvp.X))
+		for i := range bvp.X {
+			accepted[i] = matrix.MakeDenseCopy(bvp.X[i])
+		}
+		cancel()
+	}
+
+	result, err := bvp.SolveWithOptionsContext(ctx, opts)
+
+	if err != context.Canceled || result.Reason != Cancelled {
+		t.Errorf("Expected cancellation, got %v with reason %s", err, result.Reason)
+	}
+
+	if calls != 1 || result.Iterations != 1 {
+		t.Errorf("Expected to stop after one iteration, got %d calls and %d iterations", calls, result.Iterations)
+		return
+	}
+
+	for i := range bvp.X {
+		if !matrix.Equals(bvp.X[i], accepted[i]) {
+			t.Errorf("X differs from the last accepted iterate at mesh index %d", i)
+			break
+		}
+	}
+}
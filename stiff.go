@@ -0,0 +1,481 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+)
+
+const (
+	maxBDFOrder     = 5
+	maxBDFNewtonIts = 4
+)
+
+// Integrates with variable order, variable step BDF in backward difference
+// form, following Shampine and Reichelt. The history is held as the
+// differences D of the interpolating polynomial on an equally spaced grid,
+// which is rescaled whenever the step size changes. Each step is solved by
+// simplified Newton iteration with the iteration matrix I - c Dfdx, where
+// Dfdx is reused across steps and only re-evaluated when Newton fails to
+// converge.
+func integrateBDF(ode *ODE, x *matrix.DenseMatrix, t0, t1 float64, beta matrix.MatrixRO, opts IVPOptions) (sol IVPSolution, err error) {
+	direction := sign(t1 - t0)
+	span := math.Abs(t1 - t0)
+
+	f, err := sol.evaluate(ode, x, t0, beta)
+	if err != nil {
+		return
+	}
+
+	sol.T = []float64{t0}
+	sol.X = []matrix.Matrix{x}
+	sol.F = []matrix.Matrix{f}
+
+	events := newEventTracker(opts.Events, x, t0, beta)
+
+	if span == 0 {
+		return
+	}
+
+	h := opts.InitialStep
+	if h <= 0 {
+		h = initialStepSize(x, f, opts, 2)
+	}
+
+	// gamma[k] = 1 + 1/2 + ... + 1/k, and the error constant of order k is
+	// 1/(k+1)
+	var gamma, errorConst [maxBDFOrder + 2]float64
+	for k := 1; k <= maxBDFOrder+1; k++ {
+		gamma[k] = gamma[k-1] + 1/float64(k)
+	}
+	for k := range errorConst {
+		errorConst[k] = 1 / float64(k+1)
+	}
+
+	newtonTol := 0.03
+	if opts.RelTol > 0 {
+		newtonTol = math.Max(10*machineEpsilon/opts.RelTol, math.Min(0.03, math.Sqrt(opts.RelTol)))
+	}
+
+	D := make([]*matrix.DenseMatrix, maxBDFOrder+3)
+	for i := range D {
+		D[i] = matrix.Zeros(ode.P, 1)
+	}
+	D[0] = x.Copy()
+	D[1] = matrix.Scaled(f, direction*h)
+
+	J, err := sol.jacobian(ode, x, t0, beta)
+	if err != nil {
+		return
+	}
+
+	var inverse *matrix.DenseMatrix
+	order, equalSteps, attempts := 1, 0, 0
+	t := t0
+
+	for {
+		if opts.MaxStep > 0 && h > opts.MaxStep {
+			changeDifferences(D, order, opts.MaxStep/h)
+			h = opts.MaxStep
+			equalSteps = 0
+			inverse = nil
+		}
+
+		currentJacobian := false
+
+		var xnew, d *matrix.DenseMatrix
+		var errorNorm float64
+		var iterations int
+
+		for accepted := false; !accepted; {
+			if attempts >= opts.MaxSteps {
+				return sol, IVPError{opts.Method, TooManySteps, t, h}
+			}
+			attempts++
+
+			remaining := math.Abs(t1 - t)
+			if h < opts.MinStep && h < remaining {
+				return sol, IVPError{opts.Method, StepSizeTooSmall, t, h}
+			}
+
+			tnew := t + direction*h
+			if h >= remaining {
+				tnew = t1
+				if h > remaining {
+					changeDifferences(D, order, remaining/h)
+					h = remaining
+					equalSteps = 0
+					inverse = nil
+				}
+			}
+			dt := tnew - t
+
+			predicted := matrix.Zeros(ode.P, 1)
+			for i := 0; i <= order; i++ {
+				predicted.Add(D[i])
+			}
+
+			psi := matrix.Zeros(ode.P, 1)
+			for i := 1; i <= order; i++ {
+				psi.Add(matrix.Scaled(D[i], gamma[i]/gamma[order]))
+			}
+
+			c := dt / gamma[order]
+
+			converged := false
+			for {
+				if inverse == nil {
+					inverse, err = matrix.Difference(matrix.Eye(ode.P), matrix.Scaled(J, c)).Inverse()
+					sol.Factorisations++
+					if err != nil {
+						inverse = nil
+						return sol, IVPError{opts.Method, SingularIterationMatrix, t, h}
+					}
+				}
+
+				converged, iterations, xnew, d, err = bdfNewton(ode, tnew, predicted, c, psi, inverse, beta, opts, newtonTol, &sol)
+				if err != nil {
+					return
+				}
+
+				if converged || currentJacobian {
+					break
+				}
+
+				J, err = sol.jacobian(ode, predicted, tnew, beta)
+				if err != nil {
+					return
+				}
+				currentJacobian = true
+				inverse = nil
+			}
+
+			if !converged {
+				sol.Rejected++
+				h = h / 2
+				changeDifferences(D, order, 0.5)
+				equalSteps = 0
+				inverse = nil
+				continue
+			}
+
+			safety := 0.9 * float64(2*maxBDFNewtonIts+1) / float64(2*maxBDFNewtonIts+iterations)
+
+			errorNorm = scaledNorm(matrix.Scaled(d, errorConst[order]), xnew, xnew, opts)
+			if errorNorm > 1 {
+				sol.Rejected++
+				factor := math.Max(0.2, safety*math.Pow(errorNorm, -1/float64(order+1)))
+				h = h * factor
+				changeDifferences(D, order, factor)
+				equalSteps = 0
+				// I - cJ depends on h
+				inverse = nil
+				continue
+			}
+
+			accepted = true
+			t = tnew
+		}
+
+		equalSteps++
+		x = xnew
+
+		D[order+2] = matrix.Difference(d, D[order+1])
+		D[order+1] = d
+		for i := order; i >= 0; i-- {
+			D[i] = matrix.Sum(D[i], D[i+1])
+		}
+
+		f, err = sol.evaluate(ode, x, t, beta)
+		if err != nil {
+			return
+		}
+
+		if stop, err := sol.accept(ode, t, x, f, beta, events); err != nil || stop {
+			return sol, err
+		}
+
+		if t == t1 {
+			return
+		}
+
+		if equalSteps < order+1 {
+			continue
+		}
+
+		// choose the order among order-1, order and order+1 allowing the
+		// largest next step
+		safety := 0.9 * float64(2*maxBDFNewtonIts+1) / float64(2*maxBDFNewtonIts+iterations)
+
+		norms := []float64{math.Inf(1), errorNorm, math.Inf(1)}
+		if order > 1 {
+			norms[0] = scaledNorm(matrix.Scaled(D[order], errorConst[order-1]), x, x, opts)
+		}
+		if order < opts.MaxOrder {
+			norms[2] = scaledNorm(matrix.Scaled(D[order+2], errorConst[order+1]), x, x, opts)
+		}
+
+		best, bestFactor := 0, 0.
+		for k, norm := range norms {
+			factor := math.Inf(1)
+			if norm > 0 {
+				factor = math.Pow(norm, -1/float64(order+k))
+			}
+			if factor > bestFactor {
+				best, bestFactor = k, factor
+			}
+		}
+
+		order += best - 1
+		factor := math.Min(10, safety*bestFactor)
+		h = h * factor
+		changeDifferences(D, order, factor)
+		equalSteps = 0
+		inverse = nil
+	}
+}
+
+// Solves the BDF equations for the step to t by simplified Newton iteration
+// from the predicted solution. Returns whether the iteration converged, the
+// number of iterations, the solution and its difference d from the
+// prediction.
+func bdfNewton(ode *ODE, t float64, predicted *matrix.DenseMatrix, c float64, psi, inverse *matrix.DenseMatrix, beta matrix.MatrixRO, opts IVPOptions, tol float64, sol *IVPSolution) (converged bool, iterations int, x, d *matrix.DenseMatrix, err error) {
+	x = predicted.Copy()
+	d = matrix.Zeros(predicted.Rows(), 1)
+
+	var oldNorm float64
+	for k := 0; k < maxBDFNewtonIts; k++ {
+		iterations = k + 1
+
+		f, err := sol.evaluate(ode, x, t, beta)
+		if err != nil {
+			return false, iterations, nil, nil, err
+		}
+
+		if !isFinite(f) {
+			return false, iterations, x, d, nil
+		}
+
+		rhs := matrix.Difference(matrix.Scaled(f, c), matrix.Sum(psi, d))
+		dx := matrix.Product(inverse, rhs)
+		norm := scaledNorm(dx, predicted, predicted, opts)
+
+		rate := 0.
+		if k > 0 {
+			rate = norm / oldNorm
+			if rate >= 1 || math.Pow(rate, float64(maxBDFNewtonIts-k))/(1-rate)*norm > tol {
+				return false, iterations, x, d, nil
+			}
+		}
+
+		x.Add(dx)
+		d.Add(dx)
+
+		if norm == 0 || (k > 0 && rate/(1-rate)*norm < tol) {
+			return true, iterations, x, d, nil
+		}
+		oldNorm = norm
+	}
+	return false, iterations, x, d, nil
+}
+
+// Rescales the backward differences D[0..order] from step h to step factor*h
+func changeDifferences(D []*matrix.DenseMatrix, order int, factor float64) {
+	R := bdfRescaling(order, factor)
+	U := bdfRescaling(order, 1)
+	RU := matrix.Product(R, U)
+
+	rescaled := make([]*matrix.DenseMatrix, order+1)
+	for i := 0; i <= order; i++ {
+		rescaled[i] = matrix.Zeros(D[0].Rows(), 1)
+		for k := 0; k <= order; k++ {
+			rescaled[i].Add(matrix.Scaled(D[k], RU.Get(k, i)))
+		}
+	}
+	copy(D, rescaled)
+}
+
+// Returns the matrix taking backward differences on a grid of step h to a
+// grid of step factor*h, up to the same matrix with factor 1
+func bdfRescaling(order int, factor float64) *matrix.DenseMatrix {
+	R := matrix.Zeros(order+1, order+1)
+	for j := 0; j <= order; j++ {
+		R.Set(0, j, 1)
+	}
+	for i := 1; i <= order; i++ {
+		for j := 1; j <= order; j++ {
+			R.Set(i, j, R.Get(i-1, j)*(float64(i-1)-factor*float64(j))/float64(i))
+		}
+	}
+	return R
+}
+
+// Integrates with the modified Rosenbrock triple of Shampine and Reichelt,
+// a second order W-method with a third order error estimate. Each step
+// solves
+//
+//	W k1 = F0 + h d T
+//	W (k2 - k1) = F(x + h k1/2, t + h/2) - k1
+//	x1 = x + h k2
+//	W k3 = F(x1, t + h) - e32 (k2 - F1) - 2 (k1 - F0) + h d T
+//
+// with W = I - h d J, d = 1/(2 + sqrt 2), e32 = 6 + sqrt 2 and T = dF/dt,
+// estimating the error by h (k1 - 2 k2 + k3) / 6. Dfdx is evaluated once per
+// accepted step and reused when a step is rejected, and W is only inverted
+// again when the step size changes. T is approximated by a forward
+// difference.
+func integrateRosenbrock(ode *ODE, x *matrix.DenseMatrix, t0, t1 float64, beta matrix.MatrixRO, opts IVPOptions) (sol IVPSolution, err error) {
+	d := 1 / (2 + math.Sqrt2)
+	e32 := 6 + math.Sqrt2
+
+	direction := sign(t1 - t0)
+	span := math.Abs(t1 - t0)
+
+	f, err := sol.evaluate(ode, x, t0, beta)
+	if err != nil {
+		return
+	}
+
+	sol.T = []float64{t0}
+	sol.X = []matrix.Matrix{x}
+	sol.F = []matrix.Matrix{f}
+
+	events := newEventTracker(opts.Events, x, t0, beta)
+
+	if span == 0 {
+		return
+	}
+
+	h := opts.InitialStep
+	if h <= 0 {
+		h = initialStepSize(x, f, opts, 3)
+	}
+
+	t := t0
+
+	J, dfdt, err := rosenbrockDerivatives(ode, x, f, t, beta, &sol)
+	if err != nil {
+		return
+	}
+
+	var inverse *matrix.DenseMatrix
+	var inverted float64
+
+	for attempts := 0; ; attempts++ {
+		if attempts >= opts.MaxSteps {
+			return sol, IVPError{opts.Method, TooManySteps, t, h}
+		}
+
+		if opts.MaxStep > 0 {
+			h = math.Min(h, opts.MaxStep)
+		}
+
+		remaining := math.Abs(t1 - t)
+		last := h >= remaining
+		if last {
+			h = remaining
+		}
+
+		if h < opts.MinStep && !last {
+			return sol, IVPError{opts.Method, StepSizeTooSmall, t, h}
+		}
+
+		dt := direction * h
+
+		if inverse == nil || inverted != dt {
+			W := matrix.Difference(matrix.Eye(ode.P), matrix.Scaled(J, dt*d))
+			inverse, err = W.Inverse()
+			sol.Factorisations++
+			if err != nil {
+				return sol, IVPError{opts.Method, SingularIterationMatrix, t, h}
+			}
+			inverted = dt
+		}
+
+		k1 := matrix.Product(inverse, matrix.Sum(f, matrix.Scaled(dfdt, dt*d)))
+
+		f1, err := sol.evaluate(ode, matrix.Sum(x, matrix.Scaled(k1, dt/2)), t+dt/2, beta)
+		if err != nil {
+			return sol, err
+		}
+
+		k2 := matrix.Sum(matrix.Product(inverse, matrix.Difference(f1, k1)), k1)
+
+		tnew := t + dt
+		if last {
+			tnew = t1
+		}
+		xnew := matrix.Sum(x, matrix.Scaled(k2, dt))
+
+		f2, err := sol.evaluate(ode, xnew, tnew, beta)
+		if err != nil {
+			return sol, err
+		}
+
+		rhs := matrix.Difference(f2, matrix.Scaled(matrix.Difference(k2, f1), e32))
+		rhs.Subtract(matrix.Scaled(matrix.Difference(k1, f), 2))
+		rhs.Add(matrix.Scaled(dfdt, dt*d))
+		k3 := matrix.Product(inverse, rhs)
+
+		e := matrix.Scaled(matrix.Sum(k1, matrix.Scaled(k2, -2), k3), dt/6)
+
+		errorNorm := math.Inf(1)
+		if isFinite(xnew) && isFinite(f2) {
+			errorNorm = scaledNorm(e, x, xnew, opts)
+		}
+		factor := 0.9 * math.Pow(errorNorm, -1./3)
+
+		if errorNorm > 1 {
+			sol.Rejected++
+			h = h * math.Max(0.2, factor)
+			continue
+		}
+
+		t, x, f = tnew, xnew, f2
+
+		if stop, err := sol.accept(ode, t, x, f, beta, events); err != nil || stop {
+			return sol, err
+		}
+
+		if last {
+			return sol, nil
+		}
+
+		J, dfdt, err = rosenbrockDerivatives(ode, x, f, t, beta, &sol)
+		if err != nil {
+			return sol, err
+		}
+		inverse = nil
+
+		h = h * math.Min(5, math.Max(0.2, factor))
+	}
+}
+
+// Returns Dfdx and a forward difference approximation to dF/dt at (x, t),
+// given f = F(x, t)
+func rosenbrockDerivatives(ode *ODE, x, f matrix.MatrixRO, t float64, beta matrix.MatrixRO, sol *IVPSolution) (J, dfdt *matrix.DenseMatrix, err error) {
+	dfdx, err := sol.jacobian(ode, x, t, beta)
+	if err != nil {
+		return
+	}
+	J = matrix.MakeDenseCopy(dfdx)
+
+	dt := math.Sqrt(machineEpsilon) * math.Max(math.Abs(t), 1)
+	fdt, err := sol.evaluate(ode, x, t+dt, beta)
+	if err != nil {
+		return
+	}
+	dfdt = matrix.Scaled(matrix.Difference(fdt, f), 1/dt)
+	return
+}
+
+// Reports whether every entry of x is finite
+func isFinite(x matrix.MatrixRO) bool {
+	for i := 0; i < x.Rows(); i++ {
+		for j := 0; j < x.Cols(); j++ {
+			if v := x.Get(i, j); math.IsNaN(v) || math.IsInf(v, 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package bvp
+
+import (
+	"github.com/sbroadfoot90/go.matrix"
+	"math"
+	"testing"
+)
+
+// Robertson's chemical kinetics problem, a standard stiff test
+var robertsonODE = NewODE(
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		y1, y2, y3 := x.Get(0, 0), x.Get(1, 0), x.Get(2, 0)
+		return matrix.MakeDenseMatrix([]float64{
+			-0.04*y1 + 1e4*y2*y3,
+			0.04*y1 - 1e4*y2*y3 - 3e7*y2*y2,
+			3e7 * y2 * y2,
+		}, 3, 1)
+	},
+	func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		y2, y3 := x.Get(1, 0), x.Get(2, 0)
+		return matrix.MakeDenseMatrix([]float64{
+			-0.04, 1e4 * y3, 1e4 * y2,
+			0.04, -1e4*y3 - 6e7*y2, -1e4 * y2,
+			0, 6e7 * y2, 0,
+		}, 3, 3)
+	},
+	3, 0,
+)
+
+func TestIntegrateStiff(t *testing.T) {
+	// reference solution at t = 40
+	expected := []float64{0.7158270687, 9.185534764e-6, 0.2841637457}
+
+	for _, method := range []IVPMethod{BDF, Rosenbrock23} {
+		opts := DefaultIVPOptions()
+		opts.Method = method
+		opts.AbsTol, opts.RelTol = 1e-10, 1e-6
+
+		sol, err := Integrate(&robertsonODE, matrix.MakeDenseMatrix([]float64{1, 0, 0}, 3, 1), 0, 40, matrix.Zeros(0, 1), opts)
+		if err != nil {
+			t.Errorf("%s: error integrating: %s", method, err)
+			continue
+		}
+
+		x := sol.X[len(sol.X)-1]
+		for j := range expected {
+			if e := math.Abs(x.Get(j, 0)-expected[j]) / expected[j]; e > 1e-3 {
+				t.Errorf("%s: relative error %g in component %d", method, e, j)
+			}
+		}
+
+		if sol.Steps > 2000 {
+			t.Errorf("%s: took %d steps on a stiff problem", method, sol.Steps)
+		}
+
+		if method == BDF && sol.Jacobians >= sol.Steps {
+			t.Errorf("BDF: expected Jacobians to be reused, got %d for %d steps", sol.Jacobians, sol.Steps)
+		}
+	}
+
+	// an explicit method needs many more steps
+	opts := DefaultIVPOptions()
+	opts.AbsTol, opts.RelTol = 1e-10, 1e-6
+	opts.MaxSteps = 2000
+	_, err := Integrate(&robertsonODE, matrix.MakeDenseMatrix([]float64{1, 0, 0}, 3, 1), 0, 40, matrix.Zeros(0, 1), opts)
+	if ivpErr, ok := err.(IVPError); !ok || ivpErr.Reason != TooManySteps || ivpErr.Method != DormandPrince54 {
+		t.Errorf("Expected Dormand-Prince to run out of steps, got %v", err)
+	}
+}
+
+func TestIntegrateStiffAccuracy(t *testing.T) {
+	for _, method := range []IVPMethod{BDF, Rosenbrock23} {
+		opts := DefaultIVPOptions()
+		opts.Method = method
+
+		sol, err := Integrate(&oscillatorODE, matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), 0, 2, matrix.Zeros(1, 1), opts)
+		if err != nil {
+			t.Errorf("%s: error integrating: %s", method, err)
+			continue
+		}
+
+		for _, tt := range []float64{0.3, 1, 2} {
+			if e := math.Abs(sol.At(tt).Get(0, 0) - math.Sin(tt)); e > 1e-4 {
+				t.Errorf("%s: error %g at t = %f", method, e, tt)
+			}
+		}
+	}
+}
+
+func TestIVPError(t *testing.T) {
+	opts := DefaultIVPOptions()
+	opts.Method = BDF
+	opts.MaxOrder = 6
+
+	if _, err := Integrate(&oscillatorODE, matrix.Zeros(2, 1), 0, 1, matrix.Zeros(1, 1), opts); err == nil {
+		t.Errorf("Expected an error for BDF order 6")
+	}
+
+	err := IVPError{Rosenbrock23, StepSizeTooSmall, 1.5, 1e-13}
+	if err.Error() != "Rosenbrock 2(3) integrator failed at t = 1.5 with step size 1e-13: step size too small" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestStiffInitialGuess(t *testing.T) {
+	bvp, err := newOscillatorMultipointBVP(41, []int{0, 40})
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	opts := DefaultIVPOptions()
+	opts.Method = BDF
+	if _, err := bvp.SolveIVPWithOptions(matrix.MakeDenseMatrix([]float64{0, 1}, 2, 1), opts); err != nil {
+		t.Errorf("Error integrating: %s", err)
+		return
+	}
+
+	result, err := bvp.SolveWithOptions(DefaultSolveOptions())
+	if err != nil {
+		t.Errorf("Error solving BVP: %s", err)
+		return
+	}
+
+	if result.Iterations > 2 {
+		t.Errorf("Expected the integrated initial guess to need at most 2 iterations, took %d", result.Iterations)
+	}
+}
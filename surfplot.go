@@ -1,13 +1,25 @@
 package bvp
 
 import (
-	"fmt"
+	"context"
 	"github.com/sbroadfoot90/go.matrix"
 	"math"
 )
 
 func Surfplotb(bvp BVP, O matrix.Matrix, resolution int, b1index int, b1i, b1f float64, b2index int, b2i, b2f float64) (b1, b2, costMatrix *matrix.DenseMatrix) {
-	bvp.Solve()
+	b1, b2, costMatrix, _ = SurfplotbContext(context.Background(), bvp, O, resolution, b1index, b1i, b1f, b2index, b2i, b2f, nil)
+	return
+}
+
+// As Surfplotb, checking ctx between grid points and during each solve, and
+// calling progress, if not nil, with the index and cost of each grid point
+// in the order they are visited. A grid point whose solve fails has cost NaN.
+// If ctx is cancelled the costs found so far are returned with ctx.Err(), and
+// if the solve at the original b fails its error is returned.
+func SurfplotbContext(ctx context.Context, bvp BVP, O matrix.Matrix, resolution int, b1index int, b1i, b1f float64, b2index int, b2i, b2f float64, progress func(i int, cost float64)) (b1, b2, costMatrix *matrix.DenseMatrix, err error) {
+	if err = bvp.SolveContext(ctx); err != nil {
+		return
+	}
 
 	y := make([]*matrix.DenseMatrix, bvp.N)
 	xtrue := make([]*matrix.DenseMatrix, bvp.N)
@@ -31,6 +43,10 @@ func Surfplotb(bvp BVP, O matrix.Matrix, resolution int, b1index int, b1i, b1f f
 	side := 4
 
 	for i := 0; i < (2*resolution+1)*(2*resolution+1); i++ {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
 		bvp.B.Set(b1index, 0, b1.Get(d1+resolution, 0))
 		bvp.B.Set(b2index, 0, b2.Get(d2+resolution, 0))
 
@@ -38,10 +54,17 @@ func Surfplotb(bvp BVP, O matrix.Matrix, resolution int, b1index int, b1i, b1f f
 			bvp.X[j] = matrix.MakeDenseCopy(xtrue[j])
 		}
 
-		bvp.Solve()
+		solveErr := bvp.SolveContext(ctx)
+		if err = ctx.Err(); err != nil {
+			return
+		}
 
 		var cost float64 = 0
 
+		if solveErr != nil {
+			cost = math.NaN()
+		}
+
 		for t := 0; t < bvp.N; t++ {
 			residual := matrix.Difference(y[t], matrix.Product(O, bvp.X[t]))
 			for j := 0; j < residual.Rows(); j++ {
@@ -52,8 +75,10 @@ func Surfplotb(bvp BVP, O matrix.Matrix, resolution int, b1index int, b1i, b1f f
 		}
 
 		costMatrix.Set(d2+resolution, d1+resolution, cost/float64(2)/float64(bvp.N))
+		if progress != nil {
+			progress(i, costMatrix.Get(d2+resolution, d1+resolution))
+		}
 		d1, d2, shell, side = spiral(d1, d2, shell, side)
-		fmt.Printf("%d, %d, %d, \n", i, d1, d2)
 	}
 
 	return
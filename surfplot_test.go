@@ -1,7 +1,9 @@
 package bvp
 
 import (
+	"context"
 	"github.com/sbroadfoot90/go.matrix"
+	"math"
 	"testing"
 )
 
@@ -50,3 +52,84 @@ func TestLorenzSurfPlotB(t *testing.T) {
 	// WriteMatrix(b2, "b2hr.csv")
 	// WriteMatrix(costMatrix, "costMatrixhr.csv")
 }
+
+func TestSurfplotbContext(t *testing.T) {
+	MattheijBVP, err := newMattheijBVP(101)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	b0, b2 := MattheijBVP.B.Get(0, 0), MattheijBVP.B.Get(2, 0)
+	O := matrix.Eye(3)
+
+	var indices []int
+	b1, _, costMatrix, err := SurfplotbContext(context.Background(), MattheijBVP, O, 1, 0, b0-0.1, b0+0.1, 2, b2-0.1, b2+0.1, func(i int, cost float64) {
+		indices = append(indices, i)
+	})
+	if err != nil {
+		t.Errorf("Error computing surface: %s", err)
+		return
+	}
+
+	if len(indices) != 9 || b1.Rows() != 3 || costMatrix.Rows() != 3 || costMatrix.Cols() != 3 {
+		t.Errorf("Expected a 3 by 3 grid, got %d progress calls", len(indices))
+	}
+
+	// the centre is the true b, visited first
+	if costMatrix.Get(1, 1) > 1e-10 {
+		t.Errorf("Expected zero cost at the true b, got %g", costMatrix.Get(1, 1))
+	}
+
+	MattheijBVP, err = newMattheijBVP(101)
+	if err != nil {
+		t.Errorf("Error creating Mattheij BVP")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, _, _, err = SurfplotbContext(ctx, MattheijBVP, O, 1, 0, b0-0.1, b0+0.1, 2, b2-0.1, b2+0.1, func(i int, cost float64) {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+	})
+
+	if err != context.Canceled || calls != 2 {
+		t.Errorf("Expected cancellation after 2 grid points, got %v after %d", err, calls)
+	}
+}
+
+func TestSurfplotbContextFailedSolve(t *testing.T) {
+	bvp, err := newOscillatorEndsBVP(11, 1)
+	if err != nil {
+		t.Errorf("Error creating BVP: %s", err)
+		return
+	}
+
+	// x'' = -x whose f returns the wrong dimensions for x > 1.5
+	bvp.ODE = NewODE(
+		func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+			if x.Get(0, 0) > 1.5 {
+				return matrix.Zeros(3, 1)
+			}
+			return matrix.MakeDenseMatrix([]float64{x.Get(1, 0), -x.Get(0, 0)}, 2, 1)
+		},
+		nil, 2, 1,
+	)
+
+	b1 := bvp.B.Get(1, 0)
+	_, _, costMatrix, err := SurfplotbContext(context.Background(), bvp, matrix.Eye(2), 1, 0, 0, 4, 1, b1-0.1, b1+0.1, nil)
+	if err != nil {
+		t.Errorf("Error computing surface: %s", err)
+		return
+	}
+
+	// only the column with x(0) = 0 can be solved
+	for i := 0; i < 3; i++ {
+		if math.IsNaN(costMatrix.Get(i, 0)) || !math.IsNaN(costMatrix.Get(i, 1)) || !math.IsNaN(costMatrix.Get(i, 2)) {
+			t.Errorf("Expected NaN cost where the solve fails, got row %d: %g, %g, %g", i, costMatrix.Get(i, 0), costMatrix.Get(i, 1), costMatrix.Get(i, 2))
+		}
+	}
+}
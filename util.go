@@ -55,10 +55,16 @@ func WriteMatrices(mats []matrix.Matrix, filename string) (err error) {
 	return
 }
 
+// Returns f, dfdx and dfdbeta for the linear ODE f = a(t, beta) x + q(t, beta)
+// given dadbeta, the derivatives of a with respect to each parameter, and
+// dqdbeta, the P by Q Jacobian of q. If both are nil dfdbeta is nil, so that
+// it is found by finite differences, and otherwise a nil one is taken as zero.
 func LinearFDfdx(
 	a, q func(float64, matrix.MatrixRO) matrix.Matrix,
+	dadbeta func(float64, matrix.MatrixRO) []matrix.Matrix,
+	dqdbeta func(float64, matrix.MatrixRO) matrix.Matrix,
 ) (
-	f, dfdx func(matrix.MatrixRO, float64, matrix.MatrixRO) matrix.Matrix,
+	f, dfdx, dfdbeta func(matrix.MatrixRO, float64, matrix.MatrixRO) matrix.Matrix,
 ) {
 
 	f = func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
@@ -69,6 +75,23 @@ func LinearFDfdx(
 		return a(t, beta)
 	}
 
+	if dadbeta == nil && dqdbeta == nil {
+		return
+	}
+
+	dfdbeta = func(x matrix.MatrixRO, t float64, beta matrix.MatrixRO) matrix.Matrix {
+		dfdbeta := matrix.Zeros(x.Rows(), beta.Rows())
+		if dqdbeta != nil {
+			dfdbeta = matrix.MakeDenseCopy(dqdbeta(t, beta))
+		}
+		if dadbeta != nil {
+			for k, dadbetak := range dadbeta(t, beta) {
+				dfdbeta.SetMatrix(0, k, matrix.Sum(dfdbeta.GetColVector(k), matrix.Product(dadbetak, x)))
+			}
+		}
+		return dfdbeta
+	}
+
 	return
 }
 
@@ -203,6 +226,30 @@ func rqTransformation(A, B, U, q []*matrix.DenseMatrix, n, m int) {
 	}
 }
 
+// Applies the transpose of the transformation applied by rqTransformation.
+// Each reflection is symmetric, so this applies them in reverse order.
+func rqTransformationTranspose(A, B, U, q []*matrix.DenseMatrix, n, m int) {
+	for i := n - 3; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			var ss1 float64 = 0.
+			for k := j; k < m; k++ {
+				ss1 = ss1 + B[i].Get(k, j)*q[i].Get(k, 0)
+			}
+			for k := 0; k < m; k++ {
+				ss1 = ss1 + A[i+1].Get(k, j)*q[i+1].Get(k, 0)
+			}
+			ss1 = ss1 / (-U[i].Get(j, 0) * B[i].Get(j, j))
+
+			for k := j; k < m; k++ {
+				q[i].Set(k, 0, q[i].Get(k, 0)-ss1*B[i].Get(k, j))
+			}
+			for k := 0; k < m; k++ {
+				q[i+1].Set(k, 0, q[i+1].Get(k, 0)-ss1*A[i+1].Get(k, j))
+			}
+		}
+	}
+}
+
 func rightBackSubstitute(B, C, D, U, q, xc []*matrix.DenseMatrix, n, m int) {
 	// #!..............................................................
 	// #!given starting values xc(1),xc(n) perform back substitution
@@ -297,8 +344,8 @@ func calculateBoundaryMatrices(B, D []*matrix.DenseMatrix, n, m int) (B1, Bn *ma
 	//!copy orthogonal transformation to boundary matrices
 	//![Q_1^T]->[ B D ]
 	//![Q_2^T]  [Bn B1]
-	B1 = matrix.Zeros(3, 3)
-	Bn = matrix.Zeros(3, 3)
+	B1 = matrix.Zeros(m, m)
+	Bn = matrix.Zeros(m, m)
 	for i := 0; i < m; i++ {
 
 		for j := 0; j < m; j++ {
@@ -312,11 +359,26 @@ func calculateBoundaryMatrices(B, D []*matrix.DenseMatrix, n, m int) (B1, Bn *ma
 	return B1, Bn
 }
 
-func exceedsTolerance(matrices []*matrix.DenseMatrix, tolerance float64) bool {
+// Solves A X = B one column of B at a time
+func solveColumns(A, B *matrix.DenseMatrix) (X *matrix.DenseMatrix, err error) {
+	X = matrix.Zeros(A.Cols(), B.Cols())
+	for j := 0; j < B.Cols(); j++ {
+		x, err := A.Solve(B.GetColVector(j))
+		if err != nil {
+			return nil, err
+		}
+		X.SetMatrix(0, j, x)
+	}
+	return
+}
+
+// Reports whether any entry of matrices exceeds absTol + relTol*|reference|.
+// A NaN entry always exceeds it
+func exceedsTolerance(matrices []*matrix.DenseMatrix, reference []matrix.Matrix, absTol, relTol float64) bool {
 	for t := range matrices {
 		for i := 0; i < matrices[t].Rows(); i++ {
 			for j := 0; j < matrices[t].Cols(); j++ {
-				if math.Abs(matrices[t].Get(i, j)) > tolerance {
+				if !(math.Abs(matrices[t].Get(i, j)) <= absTol+relTol*math.Abs(reference[t].Get(i, j))) {
 					return true
 				}
 			}
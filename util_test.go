@@ -2,6 +2,7 @@ package bvp
 
 import (
 	"github.com/sbroadfoot90/go.matrix"
+	"math"
 	"os"
 	"testing"
 )
@@ -34,12 +35,12 @@ func TestWriteMatrix(t *testing.T) {
 
 	n, err := tempCsv.Read(tempCsvContents)
 	if n != len(expectedContents) {
-		t.Error("Length of temp.csv is incorrect, expected: %d, got %d", len(expectedContents), n)
+		t.Errorf("Length of temp.csv is incorrect, expected: %d, got %d", len(expectedContents), n)
 	}
 
 	for i := range tempCsvContents {
 		if expectedContents[i] != tempCsvContents[i] {
-			t.Error("Character %d is incorrect, expected: %c, got %c", i, expectedContents[i], tempCsvContents[i])
+			t.Errorf("Character %d is incorrect, expected: %c, got %c", i, expectedContents[i], tempCsvContents[i])
 		}
 	}
 
@@ -63,4 +64,45 @@ func TestLinearFDfdxDfdbeta(t *testing.T) {
 	if !matrix.ApproxEquals(MattheijDfdx(x, time, beta), MattheijA(time, beta), 10e-8) {
 		t.Error("dfdx function in linearFDfdxDfdbeta is broken")
 	}
+
+	dfdbeta := MattheijDfdbeta(x, time, beta)
+	eps := 1e-6
+	for k := 0; k < 2; k++ {
+		betaPlus, betaMinus := beta.Copy(), beta.Copy()
+		betaPlus.Set(k, 0, beta.Get(k, 0)+eps)
+		betaMinus.Set(k, 0, beta.Get(k, 0)-eps)
+		column := matrix.Scaled(matrix.Difference(MattheijF(x, time, betaPlus), MattheijF(x, time, betaMinus)), 1/(2*eps))
+
+		if !matrix.ApproxEquals(dfdbeta.DenseMatrix().GetColVector(k), column, 10e-8) {
+			t.Errorf("dfdbeta function in linearFDfdxDfdbeta is broken for parameter %d", k)
+		}
+	}
+}
+
+func TestLinearFDfdxWithoutDfdbeta(t *testing.T) {
+	f, dfdx, dfdbeta := LinearFDfdx(MattheijA, MattheijQ, nil, nil)
+
+	if f == nil || dfdx == nil || dfdbeta != nil {
+		t.Errorf("Expected f and dfdx, and a nil dfdbeta without da/dbeta and dq/dbeta")
+	}
+
+	_, _, dfdbeta = LinearFDfdx(MattheijA, MattheijQ, MattheijDadbeta, nil)
+	x := matrix.Ones(3, 1)
+	beta := matrix.MakeDenseMatrix([]float64{19, 2}, 2, 1)
+	if !matrix.ApproxEquals(dfdbeta(x, 0.5, beta), MattheijDfdbeta(x, 0.5, beta), 1e-12) {
+		t.Errorf("Expected a nil dq/dbeta to be taken as zero")
+	}
+}
+
+func TestExceedsToleranceNaN(t *testing.T) {
+	reference := []matrix.Matrix{matrix.Ones(2, 1)}
+
+	if exceedsTolerance([]*matrix.DenseMatrix{matrix.Zeros(2, 1)}, reference, 1e-8, 1e-8) {
+		t.Errorf("Zero step should be within tolerance")
+	}
+
+	step := matrix.MakeDenseMatrix([]float64{0, math.NaN()}, 2, 1)
+	if !exceedsTolerance([]*matrix.DenseMatrix{step}, reference, 1e-8, 1e-8) {
+		t.Errorf("NaN step should exceed tolerance")
+	}
 }